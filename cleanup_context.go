@@ -0,0 +1,54 @@
+package nstd
+
+import (
+	"context"
+	"time"
+)
+
+var _ context.Context = (*CleanupContext)(nil)
+
+// CleanupContext is a context that outlives the cancellation of its parent, e.g. to flush data once a ShutdownContext is done.
+// it carries the values of its parent and is bounded by its own budget, which can be divided among cleanup phases.
+type CleanupContext struct {
+	context.Context
+	deadline time.Time
+}
+
+// NewCleanupContext creates a new context that carries the values of ctx but is not canceled when ctx is done.
+// the context is done once budget has elapsed from now, a non-positive budget means no deadline.
+// it is usually created once the ShutdownContext is done, and is built on context.WithoutCancel.
+func NewCleanupContext(ctx context.Context, budget time.Duration) (*CleanupContext, context.CancelFunc) {
+	c := &CleanupContext{}
+	ctx = context.WithoutCancel(ctx)
+
+	var cancel context.CancelFunc
+	if budget > 0 {
+		c.deadline = time.Now().Add(budget)
+		c.Context, cancel = context.WithDeadline(ctx, c.deadline)
+	} else {
+		c.Context, cancel = context.WithCancel(ctx)
+	}
+
+	return c, cancel
+}
+
+// Remaining returns the time left in the budget, it is zero once the budget is exhausted.
+// it returns -1 if the budget has no deadline.
+func (c *CleanupContext) Remaining() time.Duration {
+	if c.deadline.IsZero() {
+		return -1
+	}
+
+	return max(time.Until(c.deadline), 0)
+}
+
+// Phase returns a context for a single cleanup phase, bounded by d and by the remaining budget.
+// a non-positive d gives the phase the whole remaining budget.
+// phases are expected to run one after another, so the time a phase does not use is left for the next ones.
+func (c *CleanupContext) Phase(d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(c)
+	}
+
+	return context.WithTimeout(c, d)
+}
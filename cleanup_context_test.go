@@ -0,0 +1,69 @@
+package nstd_test
+
+import (
+	"context"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+type cleanupKey struct{}
+
+func TestCleanupContext(t *testing.T) {
+	t.Run("outlive the parent", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			parent, cancelParent := context.WithCancel(context.WithValue(t.Context(), cleanupKey{}, "value"))
+			cancelParent()
+
+			ctx, cancel := NewCleanupContext(parent, 10*time.Second)
+			defer cancel()
+			RequireNil(t, ctx.Err())
+			RequireEqual(t, ctx.Value(cleanupKey{}).(string), "value")
+			RequireEqual(t, ctx.Remaining(), 10*time.Second)
+
+			deadline, ok := ctx.Deadline()
+			RequireTrue(t, ok)
+			RequireEqual(t, deadline, time.Now().Add(10*time.Second))
+
+			time.Sleep(10 * time.Second)
+			synctest.Wait()
+			RequireErrIs(t, ctx.Err(), context.DeadlineExceeded)
+			RequireEqual(t, ctx.Remaining(), time.Duration(0))
+		})
+	})
+
+	t.Run("no budget", func(t *testing.T) {
+		ctx, cancel := NewCleanupContext(t.Context(), 0)
+		_, ok := ctx.Deadline()
+		RequireTrue(t, !ok)
+		RequireEqual(t, ctx.Remaining(), time.Duration(-1))
+
+		cancel()
+		RequireErrIs(t, ctx.Err(), context.Canceled)
+	})
+
+	t.Run("divide the budget among phases", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := NewCleanupContext(t.Context(), 10*time.Second)
+			defer cancel()
+
+			flush, cancelFlush := ctx.Phase(3 * time.Second)
+			deadline, _ := flush.Deadline()
+			RequireEqual(t, deadline, time.Now().Add(3*time.Second))
+			time.Sleep(time.Second)
+			cancelFlush()
+
+			drain, cancelDrain := ctx.Phase(20 * time.Second)
+			defer cancelDrain()
+			deadline, _ = drain.Deadline()
+			RequireEqual(t, deadline, time.Now().Add(9*time.Second))
+
+			rest, cancelRest := ctx.Phase(0)
+			defer cancelRest()
+			deadline, _ = rest.Deadline()
+			RequireEqual(t, deadline, time.Now().Add(9*time.Second))
+		})
+	})
+}
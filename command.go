@@ -0,0 +1,310 @@
+//go:build unix
+
+package nstd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultCommandGracePeriod is used when CommandOptions.GracePeriod is not set.
+	defaultCommandGracePeriod = 10 * time.Second
+	// maxCommandLineLength is the length after which a line of output is split.
+	maxCommandLineLength = 64 << 10
+)
+
+var _ exitCoder = (*CommandError)(nil)
+
+// CommandOptions configures RunCommand.
+type CommandOptions struct {
+	_ struct{}
+	// GracePeriod is the time given to the process group to exit after syscall.SIGTERM
+	// before it is killed with syscall.SIGKILL, 10s if not set.
+	// it also bounds the wait for the output once the process has exited.
+	GracePeriod time.Duration
+	// Logger receives every line of stdout and stderr, the output is not logged if not set.
+	Logger *slog.Logger
+	// Stdout receives every line of stdout, e.g. a BufferedWriter.
+	Stdout io.Writer
+	// Stderr receives every line of stderr, e.g. a BufferedWriter.
+	// it must be safe for concurrent use if it is also Stdout.
+	Stderr io.Writer
+}
+
+// CommandError is returned by RunCommand when the command does not exit successfully.
+type CommandError struct {
+	_ struct{}
+	// Command is the name of the command.
+	Command string
+	// Code is the exit status of the process, or -1 if it was terminated by a signal.
+	Code int
+	// Signal is the signal that terminated the process, if any.
+	Signal os.Signal
+	// Terminated reports whether the process group was asked to exit because the context was done.
+	Terminated bool
+	// Orphaned reports whether the process exited but other members of its process group kept its output open
+	// past the grace period, the process group is then asked to exit and the remaining output is lost.
+	Orphaned bool
+	// Killed reports whether the process group was killed because it did not exit within the grace period.
+	Killed bool
+	// Cause is the cause of the context when Terminated is true, or exec.ErrWaitDelay when Orphaned is true.
+	Cause error
+}
+
+// Error returns a message describing how the command exited.
+func (e *CommandError) Error() string {
+	var msg string
+	switch {
+	case e.Signal != nil:
+		msg = fmt.Sprintf("command %q terminated by signal %s", e.Command, e.Signal)
+	case e.Code != 0:
+		msg = fmt.Sprintf("command %q exited with code %d", e.Command, e.Code)
+	case e.Orphaned:
+		msg = fmt.Sprintf("command %q exited leaving its process group running", e.Command)
+	default:
+		msg = fmt.Sprintf("command %q terminated", e.Command)
+	}
+	if e.Killed {
+		msg += " after the grace period"
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+
+	return msg
+}
+
+// Unwrap returns the cause of the context when the command was terminated, or exec.ErrWaitDelay when it was orphaned.
+func (e *CommandError) Unwrap() error {
+	return e.Cause
+}
+
+// ExitCode returns the exit status of the process, or 128 plus the signal number if it was terminated by a signal.
+func (e *CommandError) ExitCode() int {
+	if e.Signal != nil {
+		return signalExitCode(e.Signal)
+	}
+
+	return e.Code
+}
+
+// RunCommand starts cmd in its own process group and waits for it to exit.
+// once ctx is done, the process group receives syscall.SIGTERM, then syscall.SIGKILL if it is still running after the grace period.
+// stdout and stderr are split into lines sent to the logger and writers of opts, cmd.Stdout and cmd.Stderr are replaced if set.
+// it returns a CommandError if the command exits with a non-zero status, is terminated by a signal or ctx is done,
+// or if the process exits while other members of its process group keep its output open past the grace period,
+// in which case the process group is terminated the same way.
+// cmd must not be created using exec.CommandContext.
+func RunCommand(ctx context.Context, cmd *exec.Cmd, opts CommandOptions) error {
+	if opts.GracePeriod <= 0 {
+		opts.GracePeriod = defaultCommandGracePeriod
+	}
+
+	name := cmd.Path
+	if len(cmd.Args) > 0 {
+		name = cmd.Args[0]
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.WaitDelay = opts.GracePeriod
+
+	stdout := newCommandOutput(opts.Logger, opts.Stdout, name, "stdout")
+	stderr := newCommandOutput(opts.Logger, opts.Stderr, name, "stderr")
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
+	if stderr != nil {
+		cmd.Stderr = stderr
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start command %q: %w", name, err)
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- cmd.Wait()
+	}()
+
+	cmdErr := &CommandError{Command: name}
+	var err error
+	select {
+	case err = <-errChan:
+	case <-ctx.Done():
+		cmdErr.Terminated = true
+		cmdErr.Cause = context.Cause(ctx)
+		err = terminateCommand(cmd.Process.Pid, errChan, opts.GracePeriod, cmdErr)
+	}
+
+	if errors.Is(err, exec.ErrWaitDelay) && !cmdErr.Terminated {
+		cmdErr.Orphaned = true
+		cmdErr.Cause = err
+		terminateProcessGroup(cmd.Process.Pid, opts.GracePeriod, cmdErr)
+	}
+
+	stdout.flush()
+	stderr.flush()
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		ws, _ := exitErr.Sys().(syscall.WaitStatus)
+		cmdErr.Code = ws.ExitStatus()
+		if ws.Signaled() {
+			cmdErr.Signal = ws.Signal()
+		}
+		return cmdErr
+	case err != nil && !errors.Is(err, exec.ErrWaitDelay):
+		return fmt.Errorf("command %q: %w", name, err)
+	case cmdErr.Terminated || cmdErr.Orphaned:
+		return cmdErr
+	default:
+		return nil
+	}
+}
+
+// terminateCommand sends syscall.SIGTERM to the process group pgid and waits for the process to exit,
+// it sends syscall.SIGKILL to the process group once gracePeriod has elapsed if any member is still running,
+// including when the process itself has already exited.
+func terminateCommand(pgid int, errChan <-chan error, gracePeriod time.Duration, cmdErr *CommandError) error {
+	_ = syscall.Kill(-pgid, syscall.SIGTERM)
+
+	t := time.NewTimer(gracePeriod)
+	defer t.Stop()
+
+	var err error
+	exited := false
+	select {
+	case err = <-errChan:
+		exited = true
+		if !waitProcessGroup(pgid, t.C) {
+			return err
+		}
+	case <-t.C:
+	}
+
+	cmdErr.Killed = true
+	// the process group may have exited since, which is reported as syscall.ESRCH.
+	_ = syscall.Kill(-pgid, syscall.SIGKILL)
+
+	if exited {
+		return err
+	}
+
+	return <-errChan
+}
+
+// terminateProcessGroup sends syscall.SIGTERM to the process group pgid once its leader has exited,
+// and syscall.SIGKILL once gracePeriod has elapsed if any member is still running.
+func terminateProcessGroup(pgid int, gracePeriod time.Duration, cmdErr *CommandError) {
+	_ = syscall.Kill(-pgid, syscall.SIGTERM)
+
+	t := time.NewTimer(gracePeriod)
+	defer t.Stop()
+
+	if waitProcessGroup(pgid, t.C) {
+		cmdErr.Killed = true
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+	}
+}
+
+// waitProcessGroup waits until every member of the process group pgid has exited or timeout fires,
+// it reports whether a member is still running.
+func waitProcessGroup(pgid int, timeout <-chan time.Time) bool {
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+
+	for {
+		if errors.Is(syscall.Kill(-pgid, 0), syscall.ESRCH) {
+			return false
+		}
+
+		select {
+		case <-timeout:
+			return true
+		case <-tick.C:
+		}
+	}
+}
+
+// commandOutput splits the output of a command into lines sent to a logger and a writer.
+type commandOutput struct {
+	logger  *slog.Logger
+	w       io.Writer
+	command string
+	stream  string
+	buf     []byte
+}
+
+// newCommandOutput creates a new commandOutput, it returns nil if both logger and w are nil.
+func newCommandOutput(logger *slog.Logger, w io.Writer, command, stream string) *commandOutput {
+	if logger == nil && w == nil {
+		return nil
+	}
+
+	return &commandOutput{
+		logger:  logger,
+		w:       w,
+		command: command,
+		stream:  stream,
+	}
+}
+
+// Write buffers p and emits every complete line.
+func (o *commandOutput) Write(p []byte) (int, error) {
+	o.buf = append(o.buf, p...)
+	for {
+		i := bytes.IndexByte(o.buf, '\n')
+		if i < 0 {
+			if len(o.buf) >= maxCommandLineLength {
+				i = maxCommandLineLength
+				o.emit(o.buf[:i])
+				o.buf = o.buf[i:]
+				continue
+			}
+			break
+		}
+		o.emit(o.buf[:i])
+		o.buf = o.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// flush emits the last line if it does not end with a newline.
+func (o *commandOutput) flush() {
+	if o == nil || len(o.buf) == 0 {
+		return
+	}
+
+	o.emit(o.buf)
+	o.buf = nil
+}
+
+// emit sends a single line to the logger and the writer.
+func (o *commandOutput) emit(line []byte) {
+	text := strings.TrimSuffix(string(line), "\r")
+	if o.logger != nil {
+		o.logger.Info("command output",
+			slog.String("command", o.command),
+			slog.String("stream", o.stream),
+			slog.String("line", text),
+		)
+	}
+	if o.w != nil {
+		_, _ = io.WriteString(o.w, text+"\n")
+	}
+}
@@ -0,0 +1,166 @@
+//go:build unix
+
+package nstd_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func TestRunCommand(t *testing.T) {
+	t.Run("stream output line by line", func(t *testing.T) {
+		var logs, stdout, stderr BytesBuffer
+		cmd := exec.Command("/bin/sh", "-c", `echo first; echo oops >&2; printf 'second\nno newline'`)
+		RequireNil(t, RunCommand(t.Context(), cmd, CommandOptions{
+			Logger: NewSlog(&logs, false, false),
+			Stdout: &stdout,
+			Stderr: &stderr,
+		}))
+
+		RequireEqual(t, stdout.String(), "first\nsecond\nno newline\n")
+		RequireEqual(t, stderr.String(), "oops\n")
+		RequireTrue(t, strings.Contains(logs.String(), `msg="command output" command=/bin/sh stream=stdout line=first`))
+		RequireTrue(t, strings.Contains(logs.String(), `msg="command output" command=/bin/sh stream=stderr line=oops`))
+		RequireTrue(t, strings.Contains(logs.String(), `msg="command output" command=/bin/sh stream=stdout line="no newline"`))
+	})
+
+	t.Run("report exit status", func(t *testing.T) {
+		err := RunCommand(t.Context(), exec.Command("/bin/sh", "-c", "exit 3"), CommandOptions{})
+		var cmdErr *CommandError
+		RequireErrAs(t, err, &cmdErr)
+		RequireEqual(t, cmdErr.Code, 3)
+		RequireTrue(t, !cmdErr.Terminated)
+		RequireEqual(t, ExitCode(err), 3)
+		RequireEqual(t, err.Error(), `command "/bin/sh" exited with code 3`)
+	})
+
+	t.Run("fail to start", func(t *testing.T) {
+		err := RunCommand(t.Context(), exec.Command("nstd-command-not-found"), CommandOptions{})
+		RequireErrIs(t, err, exec.ErrNotFound)
+		RequireTrue(t, strings.HasPrefix(err.Error(), `start command "nstd-command-not-found": `))
+	})
+
+	t.Run("terminate the process group", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(t.Context())
+		var stdout BytesBuffer
+		// the shell does not forward SIGTERM, so sleep holds stdout until it is terminated too.
+		cmd := exec.Command("/bin/sh", "-c", "echo started; sleep 30; echo done")
+		time.AfterFunc(100*time.Millisecond, func() {
+			cancel(errors.ErrUnsupported)
+		})
+
+		startedAt := time.Now()
+		err := RunCommand(ctx, cmd, CommandOptions{GracePeriod: 5 * time.Second, Stdout: &stdout})
+		RequireTrue(t, time.Since(startedAt) < 5*time.Second)
+
+		var cmdErr *CommandError
+		RequireErrAs(t, err, &cmdErr)
+		RequireTrue(t, cmdErr.Terminated)
+		RequireTrue(t, !cmdErr.Killed)
+		RequireEqual(t, cmdErr.Signal.(syscall.Signal), syscall.SIGTERM)
+		RequireErrIs(t, err, errors.ErrUnsupported)
+		RequireEqual(t, ExitCode(err), 143)
+		RequireEqual(t, err.Error(), `command "/bin/sh" terminated by signal terminated: unsupported operation`)
+		RequireEqual(t, stdout.String(), "started\n")
+	})
+
+	t.Run("exit gracefully once terminated", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cmd := exec.Command("/bin/sh", "-c", `trap 'exit 0' TERM; echo started; while :; do sleep 0.01; done`)
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		err := RunCommand(ctx, cmd, CommandOptions{GracePeriod: 5 * time.Second})
+		var cmdErr *CommandError
+		RequireErrAs(t, err, &cmdErr)
+		RequireTrue(t, cmdErr.Terminated)
+		RequireEqual(t, cmdErr.Code, 0)
+		RequireErrIs(t, err, context.Canceled)
+		RequireEqual(t, err.Error(), `command "/bin/sh" terminated: context canceled`)
+	})
+
+	t.Run("kill after the grace period", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cmd := exec.Command("/bin/sh", "-c", `trap '' TERM; while :; do sleep 0.01; done`)
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		err := RunCommand(ctx, cmd, CommandOptions{GracePeriod: 100 * time.Millisecond})
+		var cmdErr *CommandError
+		RequireErrAs(t, err, &cmdErr)
+		RequireTrue(t, cmdErr.Terminated)
+		RequireTrue(t, cmdErr.Killed)
+		RequireEqual(t, cmdErr.Signal.(syscall.Signal), syscall.SIGKILL)
+		RequireEqual(t, ExitCode(err), 137)
+		RequireEqual(t, err.Error(), `command "/bin/sh" terminated by signal killed after the grace period: context canceled`)
+	})
+
+	t.Run("kill the process group once the process has exited", func(t *testing.T) {
+		if _, err := os.Stat("/proc/self/stat"); err != nil {
+			t.Skip("requires procfs")
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		var stdout BytesBuffer
+		cmd := exec.Command("/bin/sh", "-c", `(trap '' TERM; exec sleep 30) >/dev/null & echo $!; trap 'exit 0' TERM; while :; do sleep 0.01; done`)
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		err := RunCommand(ctx, cmd, CommandOptions{GracePeriod: 200 * time.Millisecond, Stdout: &stdout})
+		var cmdErr *CommandError
+		RequireErrAs(t, err, &cmdErr)
+		RequireTrue(t, cmdErr.Terminated)
+		RequireTrue(t, cmdErr.Killed)
+		RequireEqual(t, cmdErr.Code, 0)
+
+		pid, err := strconv.Atoi(strings.TrimSpace(stdout.String()))
+		RequireNil(t, err)
+		RequireTrue(t, !processRunning(pid))
+	})
+
+	t.Run("terminate the process group holding the output", func(t *testing.T) {
+		if _, err := os.Stat("/proc/self/stat"); err != nil {
+			t.Skip("requires procfs")
+		}
+
+		var stdout BytesBuffer
+		cmd := exec.Command("/bin/sh", "-c", `sleep 30 & echo $!`)
+
+		err := RunCommand(t.Context(), cmd, CommandOptions{GracePeriod: 100 * time.Millisecond, Stdout: &stdout})
+		var cmdErr *CommandError
+		RequireErrAs(t, err, &cmdErr)
+		RequireTrue(t, cmdErr.Orphaned)
+		RequireTrue(t, !cmdErr.Terminated)
+		RequireEqual(t, cmdErr.Code, 0)
+		RequireErrIs(t, err, exec.ErrWaitDelay)
+		RequireTrue(t, strings.HasPrefix(err.Error(), `command "/bin/sh" exited leaving its process group running`))
+
+		pid, err := strconv.Atoi(strings.TrimSpace(stdout.String()))
+		RequireNil(t, err)
+		RequireTrue(t, !processRunning(pid))
+	})
+}
+
+// processRunning reports whether the process pid is running, a zombie waiting to be reaped is not running.
+func processRunning(pid int) bool {
+	for range 100 {
+		stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+		if err != nil {
+			return false
+		}
+		// the state follows the command name, which is enclosed in parentheses.
+		i := strings.LastIndexByte(string(stat), ')')
+		if i >= 0 && i+2 < len(stat) && stat[i+2] == 'Z' {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return true
+}
@@ -0,0 +1,208 @@
+package nstd
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears bounds the search for the next activation of a cron schedule that never matches, e.g. "0 0 30 2 *".
+const cronSearchYears = 5
+
+// Schedule computes when a job runs.
+type Schedule interface {
+	// Next returns the first activation strictly after t, or the zero time if the schedule never activates again.
+	Next(t time.Time) time.Time
+}
+
+// jitterSchedule is a Schedule whose runs are delayed by a random jitter that does not shift the following activations.
+type jitterSchedule interface {
+	Schedule
+	// jitter returns the random delay of a run.
+	jitter() time.Duration
+}
+
+var _ jitterSchedule = intervalSchedule{}
+
+// intervalSchedule activates at a fixed interval with a random jitter.
+type intervalSchedule struct {
+	interval  time.Duration
+	maxJitter time.Duration
+}
+
+// Every returns a Schedule that activates every interval.
+// a Scheduler delays every run by a random duration up to jitter, without shifting the following activations,
+// so the job still runs every interval on average.
+// it panics if interval is not positive.
+func Every(interval, jitter time.Duration) Schedule {
+	if interval <= 0 {
+		panic(fmt.Sprintf("nstd: invalid schedule interval %s", interval))
+	}
+
+	return intervalSchedule{interval: interval, maxJitter: max(jitter, 0)}
+}
+
+// Next returns t plus the interval, the jitter is not included.
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// jitter returns a random duration up to the jitter given to Every.
+func (s intervalSchedule) jitter() time.Duration {
+	if s.maxJitter <= 0 {
+		return 0
+	}
+
+	return rand.N(s.maxJitter + 1)
+}
+
+// cronField is the range of a single field of a cron expression.
+type cronField struct {
+	name     string
+	min, max int
+}
+
+// cronFields lists the fields of a cron expression in order.
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+// cronSchedule activates at the times matching a cron expression, each field is a bit set of the matching values.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny report whether the day fields are unrestricted,
+	// if both are restricted a day matches when either of them matches.
+	domAny, dowAny bool
+}
+
+// ParseCron parses a standard 5-field cron expression: minute, hour, day of month, month and day of week.
+// every field accepts "*", values, ranges "a-b", lists "a,b" and steps "*/n" or "a-b/n".
+// the day of week is 0 to 7, both 0 and 7 being Sunday, and the schedule uses the location of the time given to Next.
+func ParseCron(expr string) (Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron %q: expected %d fields, got %d", expr, len(cronFields), len(fields))
+	}
+
+	var sets [len(cronFields)]uint64
+	for i, f := range fields {
+		set, err := parseCronField(f, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday is both 0 and 7.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &cronSchedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField parses a single field of a cron expression into a bit set of the matching values.
+func parseCronField(s string, f cronField) (uint64, error) {
+	var set uint64
+	for part := range strings.SplitSeq(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, stepStr)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseCronValue(loStr, f); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseCronValue(hiStr, f); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s: invalid range %q", f.name, rng)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+
+	return set, nil
+}
+
+// parseCronValue parses a single value of a cron field.
+func parseCronValue(s string, f cronField) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: invalid value %q, expected %d to %d", f.name, s, f.min, f.max)
+	}
+
+	return v, nil
+}
+
+// Next returns the first minute strictly after t matching the expression, or the zero time if none matches within 5 years.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !has(s.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// matchDay reports whether the day of t matches the day of month and day of week fields.
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+
+	return dom || dow
+}
+
+// has reports whether v is in the bit set.
+func has(set uint64, v int) bool {
+	return set&(1<<v) != 0
+}
@@ -0,0 +1,66 @@
+package nstd_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func TestParseCron(t *testing.T) {
+	// 2026-03-14 is a Saturday.
+	from := time.Date(2026, time.March, 14, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.March, 14, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.March, 14, 10, 30, 0, 0, time.UTC)},
+		{"5 * * * *", time.Date(2026, time.March, 14, 11, 5, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, time.March, 14, 13, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2026, time.March, 15, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1-5", time.Date(2026, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 20 * 0", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"10,40 8 1 1,7 *", time.Date(2026, time.July, 1, 8, 10, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := ParseCron(tt.expr)
+			RequireNil(t, err)
+			RequireEqual(t, s.Next(from), tt.want)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for expr, msg := range map[string]string{
+			"* * * *":       `cron "* * * *": expected 5 fields, got 4`,
+			"60 * * * *":    `cron "60 * * * *": minute: invalid value "60", expected 0 to 59`,
+			"* * 0 * *":     `cron "* * 0 * *": day of month: invalid value "0", expected 1 to 31`,
+			"*/0 * * * *":   `cron "*/0 * * * *": minute: invalid step "0"`,
+			"* 5-2 * * *":   `cron "* 5-2 * * *": hour: invalid range "5-2"`,
+			"* * * JAN *":   `cron "* * * JAN *": month: invalid value "JAN", expected 1 to 12`,
+			"* * * * 1-8/2": `cron "* * * * 1-8/2": day of week: invalid value "8", expected 0 to 7`,
+		} {
+			_, err := ParseCron(expr)
+			RequireNotNil(t, err)
+			RequireEqual(t, err.Error(), msg)
+		}
+	})
+}
+
+func TestEvery(t *testing.T) {
+	from := time.Date(2026, time.March, 14, 10, 17, 30, 0, time.UTC)
+	RequireEqual(t, Every(time.Minute, 0).Next(from), from.Add(time.Minute))
+
+	RequireEqual(t, Every(time.Minute, 10*time.Second).Next(from), from.Add(time.Minute))
+
+	defer func() {
+		RequireEqual(t, recover().(string), "nstd: invalid schedule interval 0s")
+	}()
+	Every(0, 0)
+}
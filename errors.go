@@ -2,42 +2,65 @@ package nstd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 )
 
 var (
-	_ error        = (*shutdownCause)(nil)
-	_ fmt.Stringer = (*shutdownCause)(nil)
-	_ os.Signal    = (*shutdownCause)(nil)
+	_ error        = (*ShutdownError)(nil)
+	_ fmt.Stringer = (*ShutdownError)(nil)
+	_ os.Signal    = (*ShutdownError)(nil)
+	_ exitCoder    = (*ShutdownError)(nil)
 )
 
-// shutdownCause is used in graceful shutdown to shows which signal triggers the graceful shutdown
-type shutdownCause struct {
-	s os.Signal
+// ShutdownError is used in graceful shutdown to shows which signal triggers the graceful shutdown.
+// it is the cause of a ShutdownContext canceled by a signal, use errors.As or SignalFromError to inspect it.
+type ShutdownError struct {
+	_ struct{}
+	// Sig is the signal that triggered the shutdown.
+	Sig os.Signal
+	// ReceivedAt is the time the signal was received.
+	ReceivedAt time.Time
 }
 
-// newShutdownCause creates a new shutdownCause with the given signal.
-func newShutdownCause(s os.Signal) *shutdownCause {
-	return &shutdownCause{s: s}
+// newShutdownError creates a new ShutdownError with the given signal received now.
+func newShutdownError(s os.Signal) *ShutdownError {
+	return &ShutdownError{Sig: s, ReceivedAt: time.Now()}
 }
 
 // Error returns the signal that causes
-func (s *shutdownCause) Error() string {
+func (s *ShutdownError) Error() string {
 	return fmt.Sprintf("%s: %s", context.Canceled, s.String())
 }
 
 // Unwrap returns the underlying error, which is context.Canceled.
-func (s *shutdownCause) Unwrap() error {
+func (s *ShutdownError) Unwrap() error {
 	return context.Canceled
 }
 
-// Signal implements the os.Signal interface, allowing shutdownCause to be used as a signal.
-func (s *shutdownCause) Signal() {
+// ExitCode returns the suggested exit code, which is 128 plus the signal number, e.g. 130 for SIGINT.
+func (s *ShutdownError) ExitCode() int {
+	return signalExitCode(s.Sig)
+}
+
+// Signal implements the os.Signal interface, allowing ShutdownError to be used as a signal.
+func (s *ShutdownError) Signal() {
 	// NoOp
 }
 
-// String returns a string representation of the shutdownCause.
-func (s *shutdownCause) String() string {
-	return s.s.String()
+// String returns a string representation of the ShutdownError.
+func (s *ShutdownError) String() string {
+	return s.Sig.String()
+}
+
+// SignalFromError returns the signal that triggered the shutdown if err wraps a ShutdownError.
+func SignalFromError(err error) (os.Signal, bool) {
+	var se *ShutdownError
+	if !errors.As(err, &se) {
+		return nil, false
+	}
+
+	return se.Sig, true
 }
@@ -2,18 +2,33 @@ package nstd
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"syscall"
 	"testing"
+	"time"
 )
 
-func TestShutdownCause(t *testing.T) {
-	err := newShutdownCause(os.Interrupt)
+func TestShutdownError(t *testing.T) {
+	err := newShutdownError(os.Interrupt)
 	RequireEqual(t, err.String(), "interrupt")
 	RequireEqual(t, err.Error(), "context canceled: interrupt")
 	RequireNotNil(t, err)
 	RequireNotNil(t, err)
 	RequireErrIs(t, err, context.Canceled)
-	var sc *shutdownCause
+	var sc *ShutdownError
 	RequireErrAs(t, err, &sc)
 	sc.Signal() // Ensure it implements os.Signal interface
+	RequireEqual(t, sc.ExitCode(), 130)
+	RequireTrue(t, time.Since(sc.ReceivedAt) < time.Minute)
+}
+
+func TestSignalFromError(t *testing.T) {
+	sig, ok := SignalFromError(fmt.Errorf("wrapped: %w", newShutdownError(syscall.SIGTERM)))
+	RequireTrue(t, ok)
+	RequireEqual(t, sig, os.Signal(syscall.SIGTERM))
+
+	sig, ok = SignalFromError(context.Canceled)
+	RequireTrue(t, !ok)
+	RequireNil(t, sig)
 }
@@ -2,9 +2,10 @@ package main
 
 import (
 	"context"
-	"errors"
 	"log/slog"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/clavinjune/nstd"
 )
@@ -12,18 +13,19 @@ import (
 func main() {
 	ctx, cancel := nstd.NewShutdownContextWithCause(context.Background())
 	defer cancel(context.Canceled)
-	errChan := make(chan error, 1)
 
-	go func() {
-		slog.Info("starting server")
-		if err := http.ListenAndServe(":0", nil); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			slog.Error("server error", "error", err)
-			errChan <- err
-		}
-	}()
+	g := nstd.NewGroup(ctx)
+	g.Go("http server", func(ctx context.Context) error {
+		return nstd.RunHTTPServer(ctx, &http.Server{Addr: ":0"}, nstd.HTTPServerOptions{
+			DrainTimeout: 10 * time.Second,
+			OnListen: func(addr net.Addr) {
+				slog.Info("starting server", "addr", addr.String())
+			},
+		})
+	})
 
-	if err := ctx.Wait(errChan); err != nil {
+	// press Ctrl+C to trigger shutdown
+	if err := g.Wait(); err != nil {
 		slog.Error(err.Error())
 	}
-	// press Ctrl+C to trigger shutdown
 }
@@ -0,0 +1,168 @@
+package nstd
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var _ flag.Value = (*FeatureGates)(nil)
+
+// FeatureMaturity describes how stable a feature gate is.
+type FeatureMaturity string
+
+const (
+	// FeatureAlpha marks an experimental feature that is usually disabled by default.
+	FeatureAlpha FeatureMaturity = "ALPHA"
+	// FeatureBeta marks a well-tested feature that may still change.
+	FeatureBeta FeatureMaturity = "BETA"
+	// FeatureGA marks a stable feature.
+	FeatureGA FeatureMaturity = "GA"
+	// FeatureDeprecated marks a feature that will be removed.
+	FeatureDeprecated FeatureMaturity = "DEPRECATED"
+)
+
+// FeatureGate declares a known feature gate with its default state and maturity.
+type FeatureGate struct {
+	_        struct{}
+	Name     string
+	Default  bool
+	Maturity FeatureMaturity
+}
+
+// FeatureGates holds the state of a set of declared feature gates.
+// it implements flag.Value and accepts comma-separated name=bool pairs, e.g. "NewCache=true,FastPath=false".
+// FeatureGates is safe for concurrent use.
+type FeatureGates struct {
+	mu      sync.RWMutex
+	known   map[string]FeatureGate
+	enabled map[string]bool
+}
+
+// NewFeatureGates creates a new FeatureGates with the given gates set to their default state.
+// it panics if a gate name is empty or declared more than once.
+func NewFeatureGates(gates ...FeatureGate) *FeatureGates {
+	g := &FeatureGates{
+		known:   make(map[string]FeatureGate, len(gates)),
+		enabled: make(map[string]bool, len(gates)),
+	}
+
+	for _, gate := range gates {
+		if gate.Name == "" {
+			panic("nstd: feature gate name must not be empty")
+		}
+		if _, ok := g.known[gate.Name]; ok {
+			panic(fmt.Sprintf("nstd: feature gate %q declared more than once", gate.Name))
+		}
+		g.known[gate.Name] = gate
+		g.enabled[gate.Name] = gate.Default
+	}
+
+	return g
+}
+
+// Set parses comma-separated name=bool pairs and updates the state of the given gates.
+// it rejects unknown gates and leaves the state untouched if any pair is invalid.
+func (g *FeatureGates) Set(value string) error {
+	updates := make(map[string]bool)
+	for pair := range strings.SplitSeq(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, raw, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid feature gate %q: missing value", pair)
+		}
+
+		name = strings.TrimSpace(name)
+		if _, ok := g.known[name]; !ok {
+			return fmt.Errorf("unknown feature gate %q", name)
+		}
+
+		enabled, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("invalid value for feature gate %q: %w", name, err)
+		}
+		updates[name] = enabled
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for name, enabled := range updates {
+		g.enabled[name] = enabled
+	}
+
+	return nil
+}
+
+// String returns the state of every gate as sorted comma-separated name=bool pairs.
+func (g *FeatureGates) String() string {
+	if g == nil {
+		return ""
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	pairs := make([]string, 0, len(g.enabled))
+	for name, enabled := range g.enabled {
+		pairs = append(pairs, fmt.Sprintf("%s=%t", name, enabled))
+	}
+	slices.Sort(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// Enabled reports whether the given gate is enabled, unknown gates are always disabled.
+func (g *FeatureGates) Enabled(name string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.enabled[name]
+}
+
+// Gates returns the declared gates sorted by name.
+func (g *FeatureGates) Gates() []FeatureGate {
+	gates := make([]FeatureGate, 0, len(g.known))
+	for _, gate := range g.known {
+		gates = append(gates, gate)
+	}
+	slices.SortFunc(gates, func(a, b FeatureGate) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return gates
+}
+
+// FeatureGates defines a feature gates flag with the given declared gates, it supports environment variables.
+// the usage is extended with the list of declared gates, their maturity and default state.
+func (fs *FlagSet) FeatureGates(name string, gates []FeatureGate, usage string) *FeatureGates {
+	f := NewFeatureGates(gates...)
+	fs.std.Var(f, name, featureGatesUsage(usage, f))
+	e, ok := fs.getFromEnv(name)
+	if !ok {
+		return f
+	}
+
+	g := NewFeatureGates(gates...)
+	if err := g.Set(e); err != nil {
+		panic(err.Error())
+	}
+
+	return g
+}
+
+// featureGatesUsage appends the declared gates of g to the given usage.
+func featureGatesUsage(usage string, g *FeatureGates) string {
+	var b strings.Builder
+	b.WriteString(usage)
+	for _, gate := range g.Gates() {
+		fmt.Fprintf(&b, "\n%s=true|false (%s - default=%t)", gate.Name, gate.Maturity, gate.Default)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package nstd_test
+
+import (
+	"flag"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	. "github.com/clavinjune/nstd"
+)
+
+var testFeatureGates = []FeatureGate{
+	{Name: "NewCache", Default: false, Maturity: FeatureAlpha},
+	{Name: "FastPath", Default: true, Maturity: FeatureBeta},
+}
+
+func TestFeatureGates(t *testing.T) {
+	t.Run("default state", func(t *testing.T) {
+		g := NewFeatureGates(testFeatureGates...)
+		RequireTrue(t, !g.Enabled("NewCache"))
+		RequireTrue(t, g.Enabled("FastPath"))
+		RequireTrue(t, !g.Enabled("Unknown"))
+		RequireEqual(t, g.String(), "FastPath=true,NewCache=false")
+	})
+
+	t.Run("set known gates", func(t *testing.T) {
+		g := NewFeatureGates(testFeatureGates...)
+		RequireNil(t, g.Set("NewCache=true, FastPath=false"))
+		RequireTrue(t, g.Enabled("NewCache"))
+		RequireTrue(t, !g.Enabled("FastPath"))
+	})
+
+	t.Run("reject unknown gate", func(t *testing.T) {
+		g := NewFeatureGates(testFeatureGates...)
+		err := g.Set("NewCache=true,Unknown=true")
+		RequireNotNil(t, err)
+		RequireEqual(t, err.Error(), `unknown feature gate "Unknown"`)
+		RequireTrue(t, !g.Enabled("NewCache"))
+	})
+
+	t.Run("reject invalid value", func(t *testing.T) {
+		g := NewFeatureGates(testFeatureGates...)
+		RequireNotNil(t, g.Set("NewCache"))
+		RequireNotNil(t, g.Set("NewCache=maybe"))
+	})
+
+	t.Run("concurrent access", func(t *testing.T) {
+		g := NewFeatureGates(testFeatureGates...)
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				_ = g.Set("NewCache=true")
+				_ = g.Enabled("NewCache")
+				_ = g.String()
+			})
+		}
+		wg.Wait()
+		RequireTrue(t, g.Enabled("NewCache"))
+	})
+}
+
+func TestFlagSet_FeatureGates(t *testing.T) {
+	t.Run("from args", func(t *testing.T) {
+		defer os.Clearenv()
+		fs := NewFlagSet("test", flag.ContinueOnError)
+		g := fs.FeatureGates("feature-gates", testFeatureGates, "usage")
+
+		RequireNil(t, fs.Parse("--feature-gates=NewCache=true,FastPath=false"))
+		RequireTrue(t, g.Enabled("NewCache"))
+		RequireTrue(t, !g.Enabled("FastPath"))
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		defer os.Clearenv()
+		t.Setenv("TEST_FEATURE_GATES", "NewCache=true")
+		fs := NewFlagSet("test", flag.ContinueOnError)
+		g := fs.FeatureGates("feature-gates", testFeatureGates, "usage")
+
+		RequireNil(t, fs.Parse("--feature-gates=NewCache=false"))
+		RequireTrue(t, g.Enabled("NewCache"))
+	})
+
+	t.Run("unknown gate from args", func(t *testing.T) {
+		defer os.Clearenv()
+		fs := NewFlagSet("test", flag.ContinueOnError)
+		fs.FlagSet().SetOutput(io.Discard)
+		_ = fs.FeatureGates("feature-gates", testFeatureGates, "usage")
+
+		RequireNotNil(t, fs.Parse("--feature-gates=Unknown=true"))
+	})
+
+	t.Run("unknown gate from env", func(t *testing.T) {
+		defer func() {
+			i := recover()
+			RequireNotNil(t, i)
+			RequireEqual(t, i.(string), `unknown feature gate "Unknown"`)
+		}()
+		defer os.Clearenv()
+		t.Setenv("TEST_FEATURE_GATES", "Unknown=true")
+		fs := NewFlagSet("test", flag.ContinueOnError)
+		_ = fs.FeatureGates("feature-gates", testFeatureGates, "usage")
+	})
+}
@@ -18,9 +18,11 @@ var (
 // FlagSet wraps flag.FlagSet to provide a structured way to manage command-line flags with environment variable support.
 // environment variable is prioritized over command-line arguments.
 type FlagSet struct {
-	_    struct{}
-	std  *flag.FlagSet
-	name string
+	_           struct{}
+	std         *flag.FlagSet
+	name        string
+	description string
+	examples    []flagExample
 }
 
 // NewFlagSet creates a new FlagSet with the given name and error handling mode.
@@ -108,18 +110,21 @@ func (fs *FlagSet) Duration(name string, value time.Duration, usage string) *tim
 }
 
 // getFromEnv retrieves the value of an environment variable constructed from the FlagSet name and the flag name.
-// it replace all non-word characters in the flag name with underscores and converts it to uppercase.
 func (fs *FlagSet) getFromEnv(name string) (string, bool) {
-	return os.LookupEnv(
-		reSymbols.ReplaceAllString(
-			strings.ToUpper(
-				fmt.Sprintf(
-					"%s_%s",
-					fs.name,
-					strings.TrimSpace(name),
-				),
+	return os.LookupEnv(fs.envName(name))
+}
+
+// envName constructs the environment variable name from the FlagSet name and the flag name.
+// it replace all non-word characters in the flag name with underscores and converts it to uppercase.
+func (fs *FlagSet) envName(name string) string {
+	return reSymbols.ReplaceAllString(
+		strings.ToUpper(
+			fmt.Sprintf(
+				"%s_%s",
+				fs.name,
+				strings.TrimSpace(name),
 			),
-			"_",
 		),
+		"_",
 	)
 }
@@ -0,0 +1,197 @@
+package nstd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// flagExample is an example invocation rendered in the reference docs.
+type flagExample struct {
+	command     string
+	description string
+}
+
+// flagDoc is the documentation of a single flag, collected from the underlying flag.FlagSet.
+type flagDoc struct {
+	name     string
+	typeName string
+	usage    string
+	defValue string
+	env      string
+}
+
+// SetDescription sets the description rendered in the reference docs.
+func (fs *FlagSet) SetDescription(description string) {
+	fs.description = strings.TrimSpace(description)
+}
+
+// AddExample adds an example invocation rendered in the reference docs.
+func (fs *FlagSet) AddExample(command, description string) {
+	fs.examples = append(fs.examples, flagExample{
+		command:     strings.TrimSpace(command),
+		description: strings.TrimSpace(description),
+	})
+}
+
+// WriteMan renders a roff man page of the FlagSet to w.
+// it lists the synopsis, flags, environment variables, defaults and examples.
+func (fs *FlagSet) WriteMan(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s 1\n", roffEscape(strings.ToUpper(fs.name)))
+
+	b.WriteString(".SH NAME\n")
+	if fs.description != "" {
+		fmt.Fprintf(&b, "%s \\- %s\n", roffEscape(fs.name), roffEscape(firstLine(fs.description)))
+	} else {
+		fmt.Fprintf(&b, "%s\n", roffEscape(fs.name))
+	}
+
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, ".B %s\n[\\fIflags\\fR]\n", roffEscape(fs.name))
+
+	if fs.description != "" {
+		b.WriteString(".SH DESCRIPTION\n")
+		b.WriteString(roffLines(fs.description))
+	}
+
+	docs := fs.flagDocs()
+	if len(docs) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+		for _, d := range docs {
+			b.WriteString(".TP\n")
+			fmt.Fprintf(&b, "\\fB\\-\\-%s\\fR", roffEscape(d.name))
+			if d.typeName != "" {
+				fmt.Fprintf(&b, " \\fI%s\\fR", roffEscape(d.typeName))
+			}
+			b.WriteString("\n")
+			b.WriteString(roffLines(d.usage))
+			if d.defValue != "" {
+				fmt.Fprintf(&b, ".br\nDefault: \\fB%s\\fR\n", roffEscape(d.defValue))
+			}
+		}
+
+		b.WriteString(".SH ENVIRONMENT\n")
+		for _, d := range docs {
+			fmt.Fprintf(&b, ".TP\n.B %s\nOverrides \\fB\\-\\-%s\\fR.\n", roffEscape(d.env), roffEscape(d.name))
+		}
+	}
+
+	if len(fs.examples) > 0 {
+		b.WriteString(".SH EXAMPLES\n")
+		for _, e := range fs.examples {
+			fmt.Fprintf(&b, ".TP\n.B %s\n", roffEscape(e.command))
+			b.WriteString(roffLines(e.description))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown renders a Markdown reference document of the FlagSet to w.
+// it lists the synopsis, flags, environment variables, defaults and examples.
+func (fs *FlagSet) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", fs.name)
+	if fs.description != "" {
+		fmt.Fprintf(&b, "%s\n\n", fs.description)
+	}
+
+	fmt.Fprintf(&b, "## Synopsis\n\n```shell\n%s [flags]\n```\n", fs.name)
+
+	if docs := fs.flagDocs(); len(docs) > 0 {
+		b.WriteString("\n## Flags\n\n")
+		b.WriteString("| Flag | Environment | Default | Description |\n")
+		b.WriteString("|------|-------------|---------|-------------|\n")
+		for _, d := range docs {
+			flagName := "--" + d.name
+			if d.typeName != "" {
+				flagName += " " + d.typeName
+			}
+			defValue := ""
+			if d.defValue != "" {
+				defValue = markdownCode(d.defValue)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+				markdownCode(flagName),
+				markdownCode(d.env),
+				defValue,
+				markdownCell(d.usage),
+			)
+		}
+	}
+
+	if len(fs.examples) > 0 {
+		b.WriteString("\n## Examples\n")
+		for _, e := range fs.examples {
+			b.WriteString("\n")
+			if e.description != "" {
+				fmt.Fprintf(&b, "%s\n\n", e.description)
+			}
+			fmt.Fprintf(&b, "```shell\n%s\n```\n", e.command)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// flagDocs collects the documentation of every defined flag, sorted by name.
+func (fs *FlagSet) flagDocs() []flagDoc {
+	var docs []flagDoc
+	fs.std.VisitAll(func(f *flag.Flag) {
+		typeName, usage := flag.UnquoteUsage(f)
+		docs = append(docs, flagDoc{
+			name:     f.Name,
+			typeName: typeName,
+			usage:    usage,
+			defValue: f.DefValue,
+			env:      fs.envName(f.Name),
+		})
+	})
+
+	return docs
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// roffEscape escapes s to be used as roff text.
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	return strings.ReplaceAll(s, "-", `\-`)
+}
+
+// roffLines escapes every line of s and separates them with line breaks.
+func roffLines(s string) string {
+	var b strings.Builder
+	for i, line := range strings.Split(s, "\n") {
+		if i > 0 {
+			b.WriteString(".br\n")
+		}
+		line = roffEscape(line)
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			line = `\&` + line
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// markdownCode wraps s in a Markdown code span that is safe to use in a table cell.
+func markdownCode(s string) string {
+	return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+}
+
+// markdownCell escapes s to be used in a Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,73 @@
+package nstd_test
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func newDocFlagSet() *FlagSet {
+	fs := NewFlagSet("myapp", flag.ContinueOnError)
+	fs.SetDescription("myapp serves things.\n.dot starts a line")
+	_ = fs.Int("port", 8080, "the `port` to listen on")
+	_ = fs.String("name", "", "service name | alias")
+	fs.AddExample("myapp --port 9090", "Listen on port 9090.")
+
+	return fs
+}
+
+func TestFlagSet_WriteMan(t *testing.T) {
+	defer os.Clearenv()
+	var b strings.Builder
+	RequireNil(t, newDocFlagSet().WriteMan(&b))
+	RequireEqual(t, b.String(), `.TH MYAPP 1
+.SH NAME
+myapp \- myapp serves things.
+.SH SYNOPSIS
+.B myapp
+[\fIflags\fR]
+.SH DESCRIPTION
+myapp serves things.
+.br
+\&.dot starts a line
+.SH OPTIONS
+.TP
+\fB\-\-name\fR \fIstring\fR
+service name | alias
+.TP
+\fB\-\-port\fR \fIport\fR
+the port to listen on
+.br
+Default: \fB8080\fR
+.SH ENVIRONMENT
+.TP
+.B MYAPP_NAME
+Overrides \fB\-\-name\fR.
+.TP
+.B MYAPP_PORT
+Overrides \fB\-\-port\fR.
+.SH EXAMPLES
+.TP
+.B myapp \-\-port 9090
+Listen on port 9090.
+`)
+}
+
+func TestFlagSet_WriteMarkdown(t *testing.T) {
+	defer os.Clearenv()
+	var b strings.Builder
+	RequireNil(t, newDocFlagSet().WriteMarkdown(&b))
+	RequireEqual(t, b.String(), "# myapp\n\n"+
+		"myapp serves things.\n.dot starts a line\n\n"+
+		"## Synopsis\n\n```shell\nmyapp [flags]\n```\n\n"+
+		"## Flags\n\n"+
+		"| Flag | Environment | Default | Description |\n"+
+		"|------|-------------|---------|-------------|\n"+
+		"| `--name string` | `MYAPP_NAME` |  | service name \\| alias |\n"+
+		"| `--port port` | `MYAPP_PORT` | `8080` | the port to listen on |\n"+
+		"\n## Examples\n\n"+
+		"Listen on port 9090.\n\n```shell\nmyapp --port 9090\n```\n")
+}
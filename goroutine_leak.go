@@ -0,0 +1,170 @@
+package nstd
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ignoredGoroutines lists the functions of the goroutines started lazily by the standard library that never exit.
+var ignoredGoroutines = []string{
+	"os/signal.signal_recv",
+	"os/signal.loop",
+	"runtime.ensureSigM",
+}
+
+// Goroutine is a goroutine parsed from runtime.Stack.
+type Goroutine struct {
+	_ struct{}
+	// ID is the goroutine id.
+	ID uint64
+	// State is the state of the goroutine, e.g. "chan receive" or "select, 2 minutes".
+	State string
+	// Function is the function the goroutine is currently running.
+	Function string
+	// CreatedBy is the function that started the goroutine.
+	CreatedBy string
+	// Stack is the full stack trace of the goroutine.
+	Stack string
+}
+
+// GoroutineSnapshot is the set of goroutines alive at a point in time, used as the baseline to find leaked goroutines.
+type GoroutineSnapshot struct {
+	_   struct{}
+	ids map[uint64]struct{}
+}
+
+// CaptureGoroutines captures the goroutines alive now, usually at startup.
+func CaptureGoroutines() *GoroutineSnapshot {
+	gs := goroutines()
+	ids := make(map[uint64]struct{}, len(gs))
+	for _, g := range gs {
+		ids[g.ID] = struct{}{}
+	}
+
+	return &GoroutineSnapshot{ids: ids}
+}
+
+// Leaked returns the goroutines that are alive but were not in the snapshot, excluding the calling goroutine.
+// goroutines usually take a moment to exit once their context is canceled,
+// so it retries until no goroutine is leaked or settle has elapsed.
+func (b *GoroutineSnapshot) Leaked(settle time.Duration) []Goroutine {
+	deadline := time.Now().Add(settle)
+	delay := time.Millisecond
+	for {
+		leaked := b.leaked()
+		if len(leaked) == 0 || !time.Now().Before(deadline) {
+			return leaked
+		}
+
+		time.Sleep(min(delay, time.Until(deadline)))
+		delay = min(2*delay, 100*time.Millisecond)
+	}
+}
+
+// leaked returns the goroutines that are alive but were not in the snapshot, excluding the calling goroutine.
+func (b *GoroutineSnapshot) leaked() []Goroutine {
+	gs := goroutines()
+	var leaked []Goroutine
+	for i, g := range gs {
+		// runtime.Stack always reports the calling goroutine first.
+		if i == 0 {
+			continue
+		}
+		if _, ok := b.ids[g.ID]; ok || slices.Contains(ignoredGoroutines, g.Function) {
+			continue
+		}
+		leaked = append(leaked, g)
+	}
+
+	return leaked
+}
+
+// LogLeakedGoroutines logs every goroutine leaked since baseline using logger, see GoroutineSnapshot.Leaked.
+// it returns the number of leaked goroutines.
+func LogLeakedGoroutines(logger *slog.Logger, baseline *GoroutineSnapshot, settle time.Duration) int {
+	leaked := baseline.Leaked(settle)
+	for _, g := range leaked {
+		logger.Warn("goroutine leaked",
+			slog.Uint64("id", g.ID),
+			slog.String("state", g.State),
+			slog.String("function", g.Function),
+			slog.String("created_by", g.CreatedBy),
+			slog.String("stack", g.Stack),
+		)
+	}
+
+	return len(leaked)
+}
+
+// RequireNoLeakedGoroutines captures the goroutines alive now, and fails the test if goroutines started by the test
+// are still alive once settle has elapsed after the test and its subtests complete.
+func RequireNoLeakedGoroutines(tb testing.TB, settle time.Duration) {
+	tb.Helper()
+
+	baseline := CaptureGoroutines()
+	tb.Cleanup(func() {
+		for _, g := range baseline.Leaked(settle) {
+			tb.Errorf("goroutine %d leaked, created by %s:\n%s", g.ID, g.CreatedBy, g.Stack)
+		}
+	})
+}
+
+// goroutines returns every goroutine alive, starting with the calling goroutine.
+func goroutines() []Goroutine {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	var gs []Goroutine
+	for block := range bytes.SplitSeq(bytes.TrimSpace(buf), []byte("\n\n")) {
+		if g, ok := parseGoroutine(string(block)); ok {
+			gs = append(gs, g)
+		}
+	}
+
+	return gs
+}
+
+// parseGoroutine parses a single goroutine block from runtime.Stack, e.g.
+//
+//	goroutine 7 [chan receive]:
+//	main.worker()
+//		/app/main.go:12 +0x28
+//	created by main.main in goroutine 1
+//		/app/main.go:7 +0x1c
+func parseGoroutine(block string) (Goroutine, bool) {
+	header, body, _ := strings.Cut(block, "\n")
+	g := Goroutine{Stack: block}
+	if _, err := fmt.Sscanf(header, "goroutine %d", &g.ID); err != nil {
+		return g, false
+	}
+	if _, state, ok := strings.Cut(header, "["); ok {
+		g.State, _, _ = strings.Cut(state, "]")
+	}
+
+	function, _, _ := strings.Cut(body, "\n")
+	if i := strings.LastIndex(function, "("); i > 0 {
+		function = function[:i]
+	}
+	g.Function = function
+
+	if _, createdBy, ok := strings.Cut(body, "created by "); ok {
+		createdBy, _, _ = strings.Cut(createdBy, "\n")
+		createdBy, _, _ = strings.Cut(createdBy, " in goroutine")
+		g.CreatedBy = createdBy
+	}
+
+	return g, true
+}
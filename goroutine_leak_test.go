@@ -0,0 +1,120 @@
+package nstd_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+type cleanupRecorder struct {
+	testing.TB
+	cleanups []func()
+	errs     []string
+}
+
+func (r *cleanupRecorder) Cleanup(fn func()) {
+	r.cleanups = append(r.cleanups, fn)
+}
+
+func (r *cleanupRecorder) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func leakyWorker(stop <-chan struct{}) {
+	<-stop
+}
+
+// waitBlocked waits until every goroutine leaked since baseline is blocked.
+func waitBlocked(t *testing.T, baseline *GoroutineSnapshot) {
+	t.Helper()
+	for range 100 {
+		blocked := true
+		for _, g := range baseline.Leaked(0) {
+			blocked = blocked && g.State != "runnable" && g.State != "running"
+		}
+		if blocked {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("goroutines are not blocked")
+}
+
+func TestGoroutineSnapshot_Leaked(t *testing.T) {
+	baseline := CaptureGoroutines()
+	RequireEqual(t, len(baseline.Leaked(0)), 0)
+
+	stop := make(chan struct{})
+	go leakyWorker(stop)
+	waitBlocked(t, baseline)
+
+	leaked := baseline.Leaked(10 * time.Millisecond)
+	RequireEqual(t, len(leaked), 1)
+	RequireEqual(t, leaked[0].Function, "github.com/clavinjune/nstd_test.leakyWorker")
+	RequireEqual(t, leaked[0].State, "chan receive")
+	RequireEqual(t, leaked[0].CreatedBy, "github.com/clavinjune/nstd_test.TestGoroutineSnapshot_Leaked")
+	RequireTrue(t, strings.HasPrefix(leaked[0].Stack, fmt.Sprintf("goroutine %d [chan receive]:", leaked[0].ID)))
+
+	time.AfterFunc(10*time.Millisecond, func() {
+		close(stop)
+	})
+	RequireEqual(t, len(baseline.Leaked(time.Second)), 0)
+}
+
+func TestLogLeakedGoroutines(t *testing.T) {
+	baseline := CaptureGoroutines()
+	stop := make(chan struct{})
+	defer close(stop)
+	go leakyWorker(stop)
+	waitBlocked(t, baseline)
+
+	var b BytesBuffer
+	RequireEqual(t, LogLeakedGoroutines(NewSlog(&b, false, false), baseline, 0), 1)
+	RequireTrue(t, strings.Contains(b.String(), `level=WARN msg="goroutine leaked"`))
+	RequireTrue(t, strings.Contains(b.String(), `state="chan receive" function=github.com/clavinjune/nstd_test.leakyWorker`))
+}
+
+func TestRequireNoLeakedGoroutines(t *testing.T) {
+	t.Run("fail on leaked goroutines", func(t *testing.T) {
+		r := &cleanupRecorder{TB: t}
+		RequireNoLeakedGoroutines(r, 0)
+
+		stop := make(chan struct{})
+		defer close(stop)
+		go leakyWorker(stop)
+
+		r.cleanups[0]()
+		RequireEqual(t, len(r.errs), 1)
+		RequireTrue(t, strings.Contains(r.errs[0], "leaked, created by github.com/clavinjune/nstd_test.TestRequireNoLeakedGoroutines"))
+	})
+
+	t.Run("pass once goroutines exit", func(t *testing.T) {
+		RequireNoLeakedGoroutines(t, time.Second)
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		go leakyWorker(ctx.Done())
+	})
+}
+
+func TestShutdownContext_GoroutineLeakReport(t *testing.T) {
+	var b BytesBuffer
+	ctx, cancel := NewShutdownContextWithCause(t.Context(),
+		WithShutdownLogger(NewSlog(&b, false, false)),
+		WithGoroutineLeakReport(10*time.Millisecond),
+	)
+	defer cancel(context.Canceled)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go leakyWorker(stop)
+	go leakyWorker(ctx.Done())
+
+	cancel(context.Canceled)
+	RequireEqual(t, ctx.Wait(nil), context.Canceled)
+	RequireEqual(t, strings.Count(b.String(), `msg="goroutine leaked"`), 1)
+	RequireTrue(t, strings.Contains(b.String(), `function=github.com/clavinjune/nstd_test.leakyWorker`))
+}
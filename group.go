@@ -0,0 +1,68 @@
+package nstd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Group runs components in separate goroutines and cancels all of them when any of them fails or the parent context is done.
+type Group struct {
+	_      struct{}
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	errs   []error
+}
+
+// NewGroup creates a new Group bound to ctx, usually a ShutdownContext.
+func NewGroup(ctx context.Context) *Group {
+	groupCtx, cancel := context.WithCancelCause(ctx)
+
+	return &Group{
+		parent: ctx,
+		ctx:    groupCtx,
+		cancel: cancel,
+	}
+}
+
+// Go runs fn in a separate goroutine using the group context.
+// if fn returns an error, the group context is canceled, unless the error is context.Canceled after the group context is done.
+func (g *Group) Go(name string, fn func(ctx context.Context) error) {
+	g.wg.Go(func() {
+		err := fn(g.ctx)
+		if err == nil || (g.ctx.Err() != nil && errors.Is(err, context.Canceled)) {
+			return
+		}
+
+		err = fmt.Errorf("component %q: %w", name, err)
+		g.mu.Lock()
+		g.errs = append(g.errs, err)
+		g.mu.Unlock()
+		g.cancel(err)
+	})
+}
+
+// Wait waits for every component to exit and returns their joined errors, each one identifying the failing component.
+// if no component failed, it returns the cause of the parent context, or nil if the parent context is not done.
+// if the parent context is a ShutdownContext, it is canceled and its hooks are run, see ShutdownContext.Wait.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(context.Canceled)
+
+	g.mu.Lock()
+	err := errors.Join(g.errs...)
+	g.mu.Unlock()
+
+	if err == nil {
+		err = context.Cause(g.parent)
+	}
+	if sc, ok := g.parent.(*ShutdownContext); ok {
+		return sc.shutdown(err)
+	}
+
+	return err
+}
@@ -0,0 +1,71 @@
+package nstd_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func TestGroup(t *testing.T) {
+	t.Run("all components succeed", func(t *testing.T) {
+		g := NewGroup(t.Context())
+		for range 3 {
+			g.Go("noop", func(context.Context) error {
+				return nil
+			})
+		}
+
+		RequireNil(t, g.Wait())
+	})
+
+	t.Run("cancel every component when one fails", func(t *testing.T) {
+		g := NewGroup(t.Context())
+		g.Go("server", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		g.Go("consumer", func(ctx context.Context) error {
+			<-ctx.Done()
+			return errors.ErrUnsupported
+		})
+		g.Go("db", func(context.Context) error {
+			return sql.ErrConnDone
+		})
+
+		err := g.Wait()
+		RequireErrIs(t, err, sql.ErrConnDone)
+		RequireErrIs(t, err, errors.ErrUnsupported)
+		RequireTrue(t, !errors.Is(err, context.Canceled))
+		RequireTrue(t, strings.Contains(err.Error(), `component "db": sql: connection is already closed`))
+		RequireTrue(t, strings.Contains(err.Error(), `component "consumer": unsupported operation`))
+	})
+
+	t.Run("cancel every component when a signal arrives", func(t *testing.T) {
+		ctx, cancel := NewShutdownContextWithCause(t.Context())
+		defer cancel(context.Canceled)
+
+		hooked := false
+		ctx.OnShutdown("hook", 0, func(context.Context) error {
+			hooked = true
+			return nil
+		})
+
+		g := NewGroup(ctx)
+		g.Go("server", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		RequireNil(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+		err := g.Wait()
+		RequireErrIs(t, err, context.Canceled)
+		RequireEqual(t, err.Error(), "context canceled: terminated")
+		RequireTrue(t, hooked)
+	})
+}
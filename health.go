@@ -0,0 +1,207 @@
+package nstd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrShuttingDown is reported by the readiness checks once the bound ShutdownContext is shutting down.
+var ErrShuttingDown = errors.New("shutting down")
+
+// HealthCheck is a named check run by Health.
+type HealthCheck struct {
+	_ struct{}
+	// Name identifies the check in the handler response.
+	Name string
+	// Check returns an error if the checked dependency is unhealthy.
+	Check func(ctx context.Context) error
+	// Timeout bounds Check, a non-positive Timeout means no deadline.
+	Timeout time.Duration
+	// CacheTTL is how long the result of Check is reused, a non-positive CacheTTL runs Check on every probe.
+	CacheTTL time.Duration
+}
+
+// healthCheck is a HealthCheck with its cached result.
+type healthCheck struct {
+	HealthCheck
+	mu        sync.Mutex
+	err       error
+	checkedAt time.Time
+	running   *healthRun
+}
+
+// healthRun is a run of a check shared by the probes waiting for it, err is set once done is closed.
+type healthRun struct {
+	done chan struct{}
+	err  error
+}
+
+// Health is a registry of liveness and readiness checks served by /livez and /readyz handlers.
+type Health struct {
+	_            struct{}
+	mu           sync.RWMutex
+	liveness     []*healthCheck
+	readiness    []*healthCheck
+	shutdownCtxs []*ShutdownContext
+}
+
+// NewHealth creates a new Health without any check.
+func NewHealth() *Health {
+	return &Health{}
+}
+
+// AddLiveness adds a check reported by the liveness handler.
+func (h *Health) AddLiveness(c HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, &healthCheck{HealthCheck: c})
+}
+
+// AddReadiness adds a check reported by the readiness handler.
+func (h *Health) AddReadiness(c HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, &healthCheck{HealthCheck: c})
+}
+
+// Bind makes the readiness fail as soon as a shutdown signal is received by s or s is canceled,
+// and makes a shutdown signal cancel s only once delay has elapsed, so load balancers stop sending traffic
+// before the components watching s, e.g. RunHTTPServer, stop accepting it.
+// a second shutdown signal received during the delay cancels s immediately,
+// and the grace period set using WithGracePeriod starts once s is canceled.
+func (h *Health) Bind(s *ShutdownContext, delay time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.shutdownCtxs = append(h.shutdownCtxs, s)
+	s.delayShutdown(delay)
+}
+
+// LivezHandler returns an http.Handler that reports the liveness checks.
+// it responds with 200 if every check passes, or 503 otherwise, listing the result of every check.
+func (h *Health) LivezHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.RLock()
+		checks := h.liveness
+		h.mu.RUnlock()
+
+		writeHealth(w, r, checks, nil)
+	})
+}
+
+// ReadyzHandler returns an http.Handler that reports the readiness checks.
+// it responds with 200 if every check passes, or 503 otherwise, listing the result of every check.
+// it always fails once the ShutdownContext given to Bind is shutting down.
+func (h *Health) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.RLock()
+		checks := h.readiness
+		var shutdownErr error
+		for _, s := range h.shutdownCtxs {
+			if s.shuttingDown() {
+				shutdownErr = ErrShuttingDown
+			}
+		}
+		h.mu.RUnlock()
+
+		writeHealth(w, r, checks, shutdownErr)
+	})
+}
+
+// writeHealth runs the checks and writes their results, shutdownErr is reported as a failing "shutdown" check.
+func writeHealth(w http.ResponseWriter, r *http.Request, checks []*healthCheck, shutdownErr error) {
+	var b strings.Builder
+	healthy := true
+
+	if shutdownErr != nil {
+		healthy = false
+		fmt.Fprintf(&b, "[-]shutdown failed: %s\n", shutdownErr)
+	}
+
+	for _, c := range checks {
+		if err := c.run(r.Context()); err != nil {
+			healthy = false
+			fmt.Fprintf(&b, "[-]%s failed: %s\n", c.Name, err)
+		} else {
+			fmt.Fprintf(&b, "[+]%s ok\n", c.Name)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = fmt.Fprint(w, b.String())
+}
+
+// run returns the cached result if it is still fresh, or waits for the running check, starting it if none is running.
+// concurrent probes share the same run, and a probe stops waiting once ctx is done without affecting the run,
+// so one client cannot fail the check for the whole CacheTTL.
+func (c *healthCheck) run(ctx context.Context) error {
+	c.mu.Lock()
+	if c.CacheTTL > 0 && !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.CacheTTL {
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+	r := c.running
+	if r == nil {
+		r = c.start(context.WithoutCancel(ctx))
+		c.running = r
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// start runs the check in the background bounded by its own timeout, it must be called with c.mu held.
+// a check that does not return within its timeout is reported as timed out,
+// and it stays the running check until it returns so a stuck check is never run concurrently.
+func (c *healthCheck) start(ctx context.Context) *healthRun {
+	r := &healthRun{done: make(chan struct{})}
+	cancel := context.CancelFunc(func() {})
+	if c.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+	}
+
+	var once sync.Once
+	finish := func(err error) {
+		once.Do(func() {
+			c.mu.Lock()
+			c.err, c.checkedAt = err, time.Now()
+			c.mu.Unlock()
+
+			r.err = err
+			close(r.done)
+		})
+	}
+	stop := context.AfterFunc(ctx, func() {
+		finish(ctx.Err())
+	})
+
+	go func() {
+		defer cancel()
+		err := c.Check(ctx)
+		stop()
+		finish(err)
+
+		c.mu.Lock()
+		c.running = nil
+		c.mu.Unlock()
+	}()
+
+	return r
+}
@@ -0,0 +1,221 @@
+package nstd_test
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func probe(t *testing.T, h http.Handler) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestHealth(t *testing.T) {
+	t.Run("report checks", func(t *testing.T) {
+		var dbErr error
+		h := NewHealth()
+		h.AddLiveness(HealthCheck{
+			Name: "ping",
+			Check: func(context.Context) error {
+				return nil
+			},
+		})
+		h.AddReadiness(HealthCheck{
+			Name: "db",
+			Check: func(context.Context) error {
+				return dbErr
+			},
+		})
+
+		code, body := probe(t, h.LivezHandler())
+		RequireEqual(t, code, http.StatusOK)
+		RequireEqual(t, body, "[+]ping ok\n")
+
+		code, body = probe(t, h.ReadyzHandler())
+		RequireEqual(t, code, http.StatusOK)
+		RequireEqual(t, body, "[+]db ok\n")
+
+		dbErr = sql.ErrConnDone
+		code, body = probe(t, h.ReadyzHandler())
+		RequireEqual(t, code, http.StatusServiceUnavailable)
+		RequireEqual(t, body, "[-]db failed: sql: connection is already closed\n")
+	})
+
+	t.Run("cache results", func(t *testing.T) {
+		calls := 0
+		h := NewHealth()
+		h.AddReadiness(HealthCheck{
+			Name:     "db",
+			CacheTTL: time.Hour,
+			Check: func(context.Context) error {
+				calls++
+				return nil
+			},
+		})
+
+		for range 3 {
+			code, _ := probe(t, h.ReadyzHandler())
+			RequireEqual(t, code, http.StatusOK)
+		}
+		RequireEqual(t, calls, 1)
+	})
+
+	t.Run("ignore abandoned probes", func(t *testing.T) {
+		h := NewHealth()
+		h.AddReadiness(HealthCheck{
+			Name:     "db",
+			CacheTTL: time.Hour,
+			Check: func(ctx context.Context) error {
+				return ctx.Err()
+			},
+		})
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		rec := httptest.NewRecorder()
+		h.ReadyzHandler().ServeHTTP(rec, httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil))
+
+		code, body := probe(t, h.ReadyzHandler())
+		RequireEqual(t, code, http.StatusOK)
+		RequireEqual(t, body, "[+]db ok\n")
+	})
+
+	t.Run("share the running check", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var calls atomic.Int32
+			release := make(chan struct{})
+			h := NewHealth()
+			h.AddReadiness(HealthCheck{
+				Name: "db",
+				Check: func(context.Context) error {
+					calls.Add(1)
+					<-release
+					return nil
+				},
+			})
+
+			for range 3 {
+				ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+				rec := httptest.NewRecorder()
+				h.ReadyzHandler().ServeHTTP(rec, httptest.NewRequestWithContext(ctx, http.MethodGet, "/", nil))
+				cancel()
+				RequireEqual(t, rec.Code, http.StatusServiceUnavailable)
+				RequireEqual(t, rec.Body.String(), "[-]db failed: context deadline exceeded\n")
+			}
+			RequireEqual(t, calls.Load(), 1)
+
+			close(release)
+			synctest.Wait()
+			code, _ := probe(t, h.ReadyzHandler())
+			RequireEqual(t, code, http.StatusOK)
+			RequireEqual(t, calls.Load(), 2)
+		})
+	})
+
+	t.Run("time out checks", func(t *testing.T) {
+		block := make(chan struct{})
+		defer close(block)
+
+		h := NewHealth()
+		h.AddLiveness(HealthCheck{
+			Name:    "stuck",
+			Timeout: time.Millisecond,
+			Check: func(context.Context) error {
+				<-block
+				return nil
+			},
+		})
+
+		code, body := probe(t, h.LivezHandler())
+		RequireEqual(t, code, http.StatusServiceUnavailable)
+		RequireEqual(t, body, "[-]stuck failed: context deadline exceeded\n")
+	})
+
+	t.Run("fail readiness before shutting the server down", func(t *testing.T) {
+		var src FakeSignalSource
+		ctx, cancel := NewShutdownContextWithCause(t.Context(),
+			WithShutdownLogger(NewSlog(&BytesBuffer{}, false, false)),
+			WithSignalSource(&src),
+		)
+		defer cancel(context.Canceled)
+
+		h := NewHealth()
+		h.Bind(ctx, 100*time.Millisecond)
+
+		var hookedAt time.Time
+		ctx.OnShutdown("hook", 0, func(context.Context) error {
+			hookedAt = time.Now()
+			return nil
+		})
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		RequireNil(t, err)
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- RunHTTPServer(ctx, &http.Server{Handler: h.ReadyzHandler()}, HTTPServerOptions{Listener: ln})
+		}()
+
+		readyz := func() int {
+			resp, err := http.Get("http://" + ln.Addr().String())
+			RequireNil(t, err)
+			defer resp.Body.Close()
+			return resp.StatusCode
+		}
+		RequireEqual(t, readyz(), http.StatusOK)
+
+		signaledAt := time.Now()
+		RequireEqual(t, src.Send(syscall.SIGTERM), 1)
+		for readyz() != http.StatusServiceUnavailable {
+			time.Sleep(time.Millisecond)
+		}
+		RequireNil(t, ctx.Err())
+
+		RequireNil(t, <-errChan)
+		RequireTrue(t, time.Since(signaledAt) >= 100*time.Millisecond)
+
+		var shutdownErr *ShutdownError
+		RequireErrAs(t, ctx.Wait(nil), &shutdownErr)
+		RequireTrue(t, hookedAt.Sub(signaledAt) >= 100*time.Millisecond)
+	})
+
+	t.Run("cancel immediately on a second signal", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var src FakeSignalSource
+			ctx, cancel := NewShutdownContextWithCause(t.Context(),
+				WithShutdownLogger(NewSlog(&BytesBuffer{}, false, false)),
+				WithSignalSource(&src),
+			)
+			defer cancel(context.Canceled)
+
+			h := NewHealth()
+			h.Bind(ctx, time.Hour)
+
+			RequireEqual(t, src.Send(syscall.SIGTERM), 1)
+			synctest.Wait()
+			code, body := probe(t, h.ReadyzHandler())
+			RequireEqual(t, code, http.StatusServiceUnavailable)
+			RequireEqual(t, body, "[-]shutdown failed: shutting down\n")
+			RequireNil(t, ctx.Err())
+
+			code, _ = probe(t, h.LivezHandler())
+			RequireEqual(t, code, http.StatusOK)
+
+			RequireEqual(t, src.Send(syscall.SIGINT), 1)
+			synctest.Wait()
+			RequireNotNil(t, ctx.Err())
+			RequireEqual(t, ExitCode(context.Cause(ctx)), 143)
+		})
+	})
+}
@@ -0,0 +1,89 @@
+package nstd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// HTTPServerOptions configures RunHTTPServer.
+type HTTPServerOptions struct {
+	_ struct{}
+	// DrainTimeout bounds the graceful shutdown, the remaining connections are closed once it has elapsed.
+	// a non-positive DrainTimeout waits until every connection is idle.
+	DrainTimeout time.Duration
+	// Listener is served instead of listening on the server address when set.
+	Listener net.Listener
+	// ListenerName takes the listener passed by systemd socket activation with this name using ActivatedListener,
+	// falling back to listening on the server address. it is ignored if Listener is set.
+	ListenerName string
+	// OnListen is called with the bound address before serving, e.g. to report the port chosen for ":0".
+	OnListen func(addr net.Addr)
+}
+
+// RunHTTPServer listens on srv.Addr and serves HTTP until ctx is done, usually a ShutdownContext.
+// once ctx is done, it gracefully shuts the server down within opts.DrainTimeout and force-closes the remaining connections afterwards.
+// it serves HTTPS if srv.TLSConfig is set, the certificates must be provided by srv.TLSConfig.
+// it returns nil if the server is shut down gracefully.
+func RunHTTPServer(ctx context.Context, srv *http.Server, opts HTTPServerOptions) error {
+	ln := opts.Listener
+	if ln == nil {
+		addr := srv.Addr
+		if addr == "" {
+			addr = ":http"
+		}
+
+		var err error
+		if opts.ListenerName != "" {
+			ln, err = ActivatedListener(opts.ListenerName, "tcp", addr)
+		} else {
+			var lc net.ListenConfig
+			ln, err = lc.Listen(ctx, "tcp", addr)
+		}
+		if err != nil {
+			return fmt.Errorf("http server: %w", err)
+		}
+	}
+
+	if opts.OnListen != nil {
+		opts.OnListen(ln.Addr())
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			errChan <- srv.ServeTLS(ln, "", "")
+		} else {
+			errChan <- srv.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errChan:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("http server on %s: %w", ln.Addr(), err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx := context.WithoutCancel(ctx)
+	if opts.DrainTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, opts.DrainTimeout)
+		defer cancel()
+	}
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		err = fmt.Errorf("http server on %s: drain within %s: %w", ln.Addr(), opts.DrainTimeout, err)
+		if closeErr := srv.Close(); closeErr != nil {
+			return errors.Join(err, closeErr)
+		}
+		return err
+	}
+
+	return nil
+}
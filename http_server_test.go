@@ -0,0 +1,115 @@
+package nstd_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func TestRunHTTPServer(t *testing.T) {
+	t.Run("serve until context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		addrChan := make(chan net.Addr, 1)
+		srv := &http.Server{
+			Addr: "127.0.0.1:0",
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = io.WriteString(w, "ok")
+			}),
+		}
+
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- RunHTTPServer(ctx, srv, HTTPServerOptions{
+				DrainTimeout: time.Second,
+				OnListen: func(addr net.Addr) {
+					addrChan <- addr
+				},
+			})
+		}()
+
+		addr := <-addrChan
+		RequireTrue(t, !strings.HasSuffix(addr.String(), ":0"))
+		resp, err := http.Get("http://" + addr.String())
+		RequireNil(t, err)
+		body, err := io.ReadAll(resp.Body)
+		RequireNil(t, err)
+		RequireNil(t, resp.Body.Close())
+		RequireEqual(t, string(body), "ok")
+
+		cancel()
+		RequireNil(t, <-errChan)
+	})
+
+	t.Run("force close after drain timeout", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		RequireNil(t, err)
+
+		started := make(chan struct{})
+		srv := &http.Server{
+			Handler: http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				close(started)
+				<-r.Context().Done()
+			}),
+		}
+
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- RunHTTPServer(ctx, srv, HTTPServerOptions{
+				DrainTimeout: 10 * time.Millisecond,
+				Listener:     ln,
+			})
+		}()
+
+		reqErr := make(chan error, 1)
+		go func() {
+			_, err := http.Get("http://" + ln.Addr().String())
+			reqErr <- err
+		}()
+
+		<-started
+		cancel()
+		err = <-errChan
+		RequireErrIs(t, err, context.DeadlineExceeded)
+		RequireTrue(t, strings.Contains(err.Error(), "drain within 10ms"))
+		RequireNotNil(t, <-reqErr)
+	})
+
+	t.Run("fall back without socket activation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		addrChan := make(chan net.Addr, 1)
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- RunHTTPServer(ctx, &http.Server{Addr: "127.0.0.1:0"}, HTTPServerOptions{
+				ListenerName: "http",
+				OnListen: func(addr net.Addr) {
+					addrChan <- addr
+				},
+			})
+		}()
+
+		RequireTrue(t, strings.HasPrefix((<-addrChan).String(), "127.0.0.1:"))
+		cancel()
+		RequireNil(t, <-errChan)
+	})
+
+	t.Run("listen error", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		RequireNil(t, err)
+		defer ln.Close()
+
+		err = RunHTTPServer(t.Context(), &http.Server{Addr: ln.Addr().String()}, HTTPServerOptions{})
+		RequireNotNil(t, err)
+		RequireTrue(t, strings.Contains(err.Error(), "address already in use"))
+	})
+}
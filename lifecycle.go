@@ -0,0 +1,184 @@
+package nstd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrDependencyCycle is returned when components of a Lifecycle depend on each other.
+	ErrDependencyCycle = errors.New("dependency cycle")
+	// ErrUnknownDependency is returned when a component of a Lifecycle depends on a component that is not added.
+	ErrUnknownDependency = errors.New("unknown dependency")
+)
+
+// Component is a long-lived part of a service, e.g. a database pool or an HTTP server, managed by a Lifecycle.
+type Component interface {
+	// Start starts the component, it should return once the component is ready.
+	Start(ctx context.Context) error
+	// Stop stops the component and releases its resources.
+	Stop(ctx context.Context) error
+}
+
+// lifecycleComponent is a Component added to a Lifecycle.
+type lifecycleComponent struct {
+	name      string
+	component Component
+	dependsOn []string
+}
+
+// Lifecycle starts components in dependency order and stops them in reverse order.
+type Lifecycle struct {
+	_          struct{}
+	mu         sync.Mutex
+	components []*lifecycleComponent
+	started    []*lifecycleComponent
+}
+
+// NewLifecycle creates a new empty Lifecycle.
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Add adds a component that is started after the components it depends on.
+// it panics if a component with the same name is already added.
+func (l *Lifecycle) Add(name string, c Component, dependsOn ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if slices.ContainsFunc(l.components, func(lc *lifecycleComponent) bool {
+		return lc.name == name
+	}) {
+		panic(fmt.Sprintf("nstd: component %q added more than once", name))
+	}
+
+	l.components = append(l.components, &lifecycleComponent{
+		name:      name,
+		component: c,
+		dependsOn: dependsOn,
+	})
+}
+
+// Start starts every component in dependency order, components without dependencies between them start in the order they were added.
+// if a component fails to start, the components already started are stopped in reverse order.
+// it returns ErrDependencyCycle or ErrUnknownDependency without starting anything if the dependencies are invalid.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	order, err := l.order()
+	if err != nil {
+		return err
+	}
+
+	for _, lc := range order {
+		if err := lc.component.Start(ctx); err != nil {
+			err = fmt.Errorf("start component %q: %w", lc.name, err)
+			if stopErr := l.stop(context.WithoutCancel(ctx)); stopErr != nil {
+				return errors.Join(err, stopErr)
+			}
+			return err
+		}
+		l.started = append(l.started, lc)
+	}
+
+	return nil
+}
+
+// Stop stops the started components in reverse start order and joins their errors.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.stop(ctx)
+}
+
+// Run starts every component and waits until ctx is done or an error is received from errChan, see Wait.
+// if ctx is a ShutdownContext, stopping the components is registered as a shutdown hook,
+// otherwise the components are stopped using a non-canceled ctx once Wait returns.
+func (l *Lifecycle) Run(ctx context.Context, errChan <-chan error) error {
+	if err := l.Start(ctx); err != nil {
+		return err
+	}
+
+	if sc, ok := ctx.(*ShutdownContext); ok {
+		sc.OnShutdown("lifecycle", 0, l.Stop)
+		return sc.Wait(errChan)
+	}
+
+	err := Wait(ctx, errChan)
+	if stopErr := l.Stop(context.WithoutCancel(ctx)); stopErr != nil {
+		return errors.Join(err, stopErr)
+	}
+
+	return err
+}
+
+// stop stops the started components in reverse start order, l.mu must be held.
+func (l *Lifecycle) stop(ctx context.Context) error {
+	var errs []error
+	for _, lc := range slices.Backward(l.started) {
+		if err := lc.component.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop component %q: %w", lc.name, err))
+		}
+	}
+	l.started = nil
+
+	return errors.Join(errs...)
+}
+
+// order sorts the components topologically using a depth-first search, l.mu must be held.
+func (l *Lifecycle) order() ([]*lifecycleComponent, error) {
+	byName := make(map[string]*lifecycleComponent, len(l.components))
+	for _, lc := range l.components {
+		byName[lc.name] = lc
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int, len(l.components))
+	order := make([]*lifecycleComponent, 0, len(l.components))
+	var path []string
+
+	var visit func(lc *lifecycleComponent) error
+	visit = func(lc *lifecycleComponent) error {
+		switch state[lc.name] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := append(slices.Clone(path[slices.Index(path, lc.name):]), lc.name)
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+		}
+
+		state[lc.name] = visiting
+		path = append(path, lc.name)
+		for _, dep := range lc.dependsOn {
+			d, ok := byName[dep]
+			if !ok {
+				return fmt.Errorf("%w: component %q depends on %q", ErrUnknownDependency, lc.name, dep)
+			}
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[lc.name] = visited
+		order = append(order, lc)
+
+		return nil
+	}
+
+	for _, lc := range l.components {
+		if err := visit(lc); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
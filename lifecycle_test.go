@@ -0,0 +1,139 @@
+package nstd_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	. "github.com/clavinjune/nstd"
+)
+
+type fakeComponent struct {
+	name     string
+	events   *[]string
+	startErr error
+	stopErr  error
+}
+
+func (c *fakeComponent) Start(context.Context) error {
+	*c.events = append(*c.events, "start "+c.name)
+	return c.startErr
+}
+
+func (c *fakeComponent) Stop(context.Context) error {
+	*c.events = append(*c.events, "stop "+c.name)
+	return c.stopErr
+}
+
+func requireEvents(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	RequireEqual(t, len(got), len(want))
+	for i := range min(len(got), len(want)) {
+		RequireEqual(t, got[i], want[i])
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	t.Run("start in dependency order and stop in reverse", func(t *testing.T) {
+		var events []string
+		l := NewLifecycle()
+		l.Add("http", &fakeComponent{name: "http", events: &events}, "db", "cache")
+		l.Add("cache", &fakeComponent{name: "cache", events: &events})
+		l.Add("db", &fakeComponent{name: "db", events: &events})
+
+		RequireNil(t, l.Start(t.Context()))
+		RequireNil(t, l.Stop(t.Context()))
+		requireEvents(t, events,
+			"start db", "start cache", "start http",
+			"stop http", "stop cache", "stop db",
+		)
+	})
+
+	t.Run("roll back started components", func(t *testing.T) {
+		var events []string
+		l := NewLifecycle()
+		l.Add("db", &fakeComponent{name: "db", events: &events})
+		l.Add("cache", &fakeComponent{name: "cache", events: &events, stopErr: errors.ErrUnsupported})
+		l.Add("http", &fakeComponent{name: "http", events: &events, startErr: sql.ErrConnDone}, "db")
+
+		err := l.Start(t.Context())
+		RequireErrIs(t, err, sql.ErrConnDone)
+		RequireErrIs(t, err, errors.ErrUnsupported)
+		RequireEqual(t, err.Error(), `start component "http": sql: connection is already closed`+"\n"+
+			`stop component "cache": unsupported operation`)
+		requireEvents(t, events,
+			"start db", "start cache", "start http",
+			"stop cache", "stop db",
+		)
+
+		events = nil
+		RequireNil(t, l.Stop(t.Context()))
+		requireEvents(t, events)
+	})
+
+	t.Run("detect cycles", func(t *testing.T) {
+		var events []string
+		l := NewLifecycle()
+		l.Add("a", &fakeComponent{name: "a", events: &events}, "b")
+		l.Add("b", &fakeComponent{name: "b", events: &events}, "c")
+		l.Add("c", &fakeComponent{name: "c", events: &events}, "a")
+
+		err := l.Start(t.Context())
+		RequireErrIs(t, err, ErrDependencyCycle)
+		RequireEqual(t, err.Error(), "dependency cycle: a -> b -> c -> a")
+		requireEvents(t, events)
+	})
+
+	t.Run("detect unknown dependencies", func(t *testing.T) {
+		var events []string
+		l := NewLifecycle()
+		l.Add("http", &fakeComponent{name: "http", events: &events}, "db")
+
+		RequireErrIs(t, l.Start(t.Context()), ErrUnknownDependency)
+	})
+
+	t.Run("panic on duplicate component", func(t *testing.T) {
+		defer func() {
+			i := recover()
+			RequireEqual(t, i.(string), `nstd: component "db" added more than once`)
+		}()
+		var events []string
+		l := NewLifecycle()
+		l.Add("db", &fakeComponent{name: "db", events: &events})
+		l.Add("db", &fakeComponent{name: "db", events: &events})
+	})
+
+	t.Run("run with ShutdownContext", func(t *testing.T) {
+		var events []string
+		ctx, cancel := NewShutdownContextWithCause(t.Context())
+		defer cancel(context.Canceled)
+		ctx.OnShutdown("flush", 0, func(context.Context) error {
+			events = append(events, "flush")
+			return nil
+		})
+
+		l := NewLifecycle()
+		l.Add("db", &fakeComponent{name: "db", events: &events})
+		l.Add("http", &fakeComponent{name: "http", events: &events}, "db")
+
+		errChan := make(chan error, 1)
+		errChan <- sql.ErrNoRows
+		RequireErrIs(t, l.Run(ctx, errChan), sql.ErrNoRows)
+		requireEvents(t, events, "start db", "start http", "stop http", "stop db", "flush")
+	})
+
+	t.Run("run with plain context", func(t *testing.T) {
+		var events []string
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		l := NewLifecycle()
+		l.Add("db", &fakeComponent{name: "db", events: &events, stopErr: sql.ErrConnDone})
+
+		err := l.Run(ctx, nil)
+		RequireErrIs(t, err, context.Canceled)
+		RequireErrIs(t, err, sql.ErrConnDone)
+		requireEvents(t, events, "start db", "stop db")
+	})
+}
@@ -115,6 +115,15 @@ func ExampleNewShutdownContextWithCause() {
 
 }
 
+func ExampleMain() {
+	nstd.Main(func(ctx context.Context) error {
+		// Main exits with 130 on SIGINT, 143 on SIGTERM, or the code given to nstd.WithExitCode.
+		return nstd.RunHTTPServer(ctx, &http.Server{Addr: ":8080"}, nstd.HTTPServerOptions{
+			DrainTimeout: 10 * time.Second,
+		})
+	})
+}
+
 func ExampleNewSlog() {
 	isDebug := true
 	isStructured := true
@@ -0,0 +1,141 @@
+//go:build unix
+
+package nstd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// pidFilePollInterval is the interval between attempts to lock a PID file held by another process.
+const pidFilePollInterval = 50 * time.Millisecond
+
+// ErrLocked is returned when a PID file is locked by another process.
+var ErrLocked = errors.New("already locked")
+
+// PIDFile is a PID file holding an exclusive flock to ensure a single instance of the process is running.
+type PIDFile struct {
+	_        struct{}
+	path     string
+	file     *os.File
+	stalePID int
+}
+
+// LockPIDFile takes an exclusive flock on the file at path, creating it if needed, and writes the process PID into it.
+// if another process holds the lock, it retries until wait has elapsed and returns ErrLocked with the holder PID.
+// a non-positive wait fails immediately.
+func LockPIDFile(path string, wait time.Duration) (*PIDFile, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		p, err := tryLockPIDFile(path)
+		if !errors.Is(err, ErrLocked) || !time.Now().Before(deadline) {
+			return p, err
+		}
+		time.Sleep(min(pidFilePollInterval, time.Until(deadline)))
+	}
+}
+
+// tryLockPIDFile makes a single attempt to lock the PID file at path.
+func tryLockPIDFile(path string) (*PIDFile, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("pid file: %w", err)
+		}
+
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			pid, _ := readPID(f)
+			_ = f.Close()
+			if errors.Is(err, syscall.EWOULDBLOCK) {
+				return nil, fmt.Errorf("pid file %s: %w by pid %d", path, ErrLocked, pid)
+			}
+			return nil, fmt.Errorf("pid file %s: %w", path, err)
+		}
+
+		// the previous holder may have removed the file between open and flock, so the lock must be on the current file.
+		if !sameFile(f, path) {
+			_ = f.Close()
+			continue
+		}
+
+		p := &PIDFile{path: path, file: f}
+		if pid, err := readPID(f); err == nil && pid != os.Getpid() {
+			p.stalePID = pid
+		}
+
+		if err := writePID(f); err != nil {
+			_ = p.Release(context.Background())
+			return nil, fmt.Errorf("pid file %s: %w", path, err)
+		}
+
+		return p, nil
+	}
+}
+
+// StalePID returns the PID left in the file by a process that no longer holds the lock, or 0 if the file was new or empty.
+func (p *PIDFile) StalePID() int {
+	return p.stalePID
+}
+
+// Path returns the path of the PID file.
+func (p *PIDFile) Path() string {
+	return p.path
+}
+
+// Release removes the PID file and releases the lock, it has the signature of a shutdown hook.
+func (p *PIDFile) Release(context.Context) error {
+	removeErr := os.Remove(p.path)
+	if errors.Is(removeErr, os.ErrNotExist) {
+		removeErr = nil
+	}
+
+	return errors.Join(removeErr, p.file.Close())
+}
+
+// Bind registers Release as a shutdown hook of s.
+func (p *PIDFile) Bind(s *ShutdownContext) {
+	s.OnShutdown("pid file", 0, p.Release)
+}
+
+// readPID reads the PID written in f.
+func readPID(f *os.File) (int, error) {
+	b := make([]byte, 32)
+	n, err := f.ReadAt(b, 0)
+	if n == 0 && err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(b[:n])))
+}
+
+// writePID replaces the content of f with the process PID.
+func writePID(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// sameFile reports whether f is still the file at path.
+func sameFile(f *os.File, path string) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	pi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(fi, pi)
+}
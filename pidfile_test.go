@@ -0,0 +1,97 @@
+//go:build unix
+
+package nstd_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func TestLockPIDFile(t *testing.T) {
+	t.Run("write pid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.pid")
+		p, err := LockPIDFile(path, 0)
+		RequireNil(t, err)
+		RequireEqual(t, p.StalePID(), 0)
+		RequireEqual(t, p.Path(), path)
+
+		b, err := os.ReadFile(path)
+		RequireNil(t, err)
+		RequireEqual(t, string(b), strconv.Itoa(os.Getpid())+"\n")
+
+		RequireNil(t, p.Release(t.Context()))
+		_, err = os.Stat(path)
+		RequireErrIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("fail fast when locked", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.pid")
+		p, err := LockPIDFile(path, 0)
+		RequireNil(t, err)
+		defer p.Release(t.Context())
+
+		_, err = LockPIDFile(path, 0)
+		RequireErrIs(t, err, ErrLocked)
+		RequireEqual(t, err.Error(), "pid file "+path+": already locked by pid "+strconv.Itoa(os.Getpid()))
+	})
+
+	t.Run("time out when locked", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.pid")
+		p, err := LockPIDFile(path, 0)
+		RequireNil(t, err)
+		defer p.Release(t.Context())
+
+		startedAt := time.Now()
+		_, err = LockPIDFile(path, 100*time.Millisecond)
+		RequireErrIs(t, err, ErrLocked)
+		RequireTrue(t, time.Since(startedAt) >= 100*time.Millisecond)
+	})
+
+	t.Run("wait for release", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.pid")
+		p, err := LockPIDFile(path, 0)
+		RequireNil(t, err)
+		time.AfterFunc(50*time.Millisecond, func() {
+			_ = p.Release(context.Background())
+		})
+
+		p2, err := LockPIDFile(path, 5*time.Second)
+		RequireNil(t, err)
+		RequireNil(t, p2.Release(t.Context()))
+	})
+
+	t.Run("detect stale pid", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.pid")
+		RequireNil(t, os.WriteFile(path, []byte("4194305\n"), 0o644))
+
+		p, err := LockPIDFile(path, 0)
+		RequireNil(t, err)
+		defer p.Release(t.Context())
+		RequireEqual(t, p.StalePID(), 4194305)
+
+		b, err := os.ReadFile(path)
+		RequireNil(t, err)
+		RequireEqual(t, string(b), strconv.Itoa(os.Getpid())+"\n")
+	})
+
+	t.Run("remove on shutdown", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.pid")
+		ctx, cancel := NewShutdownContextWithCause(t.Context())
+		defer cancel(context.Canceled)
+
+		p, err := LockPIDFile(path, 0)
+		RequireNil(t, err)
+		p.Bind(ctx)
+
+		cancel(context.Canceled)
+		RequireEqual(t, ctx.Wait(nil), context.Canceled)
+		_, err = os.Stat(path)
+		RequireErrIs(t, err, os.ErrNotExist)
+	})
+}
@@ -0,0 +1,88 @@
+package nstd
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// exitCoder is implemented by errors that carry a process exit code.
+type exitCoder interface {
+	ExitCode() int
+}
+
+var _ exitCoder = (*exitCodeError)(nil)
+
+// exitCodeError wraps an error with a custom process exit code.
+type exitCodeError struct {
+	err  error
+	code int
+}
+
+// Error returns the message of the wrapped error.
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// ExitCode returns the custom process exit code.
+func (e *exitCodeError) ExitCode() int {
+	return e.code
+}
+
+// WithExitCode wraps err so Main exits the process with code, it returns nil if err is nil.
+func WithExitCode(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+
+	return &exitCodeError{err: err, code: code}
+}
+
+// ExitCode returns the process exit code for err.
+// it returns 0 for nil, the code of the first error in the tree carrying one, e.g. WithExitCode or a shutdown signal,
+// and 1 otherwise.
+// a shutdown signal maps to 128 plus the signal number, e.g. 130 for SIGINT and 143 for SIGTERM.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var ec exitCoder
+	if errors.As(err, &ec) {
+		return ec.ExitCode()
+	}
+
+	return 1
+}
+
+// Main runs fn with a ShutdownContext configured by opts and exits the process once fn returns.
+// the ShutdownContext is canceled and its hooks are run after fn returns, see ShutdownContext.Wait.
+// a context.Canceled returned by fn after a shutdown is replaced by the cause of the shutdown.
+// the final error is logged using the logger set by WithShutdownLogger and the exit code is chosen by ExitCode,
+// an error with a zero exit code, e.g. ErrUpgraded, is logged at the info level.
+func Main(fn func(ctx context.Context) error, opts ...ShutdownOption) {
+	// the context is canceled by ctx.shutdown, since a deferred cancel would never run before osExit.
+	ctx, _ := NewShutdownContextWithCause(context.Background(), opts...)
+
+	err := fn(ctx)
+	if err == nil || (ctx.Err() != nil && errors.Is(err, context.Canceled)) {
+		err = context.Cause(ctx)
+	}
+
+	err = ctx.shutdown(err)
+	code := ExitCode(err)
+	switch {
+	case err == nil:
+	case code == 0:
+		ctx.logger.Info("exiting", slog.Int("code", code), slog.Any("error", err))
+	default:
+		ctx.logger.Error("exiting", slog.Int("code", code), slog.Any("error", err))
+	}
+
+	osExit(code)
+}
@@ -0,0 +1,84 @@
+package nstd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	RequireEqual(t, ExitCode(nil), 0)
+	RequireEqual(t, ExitCode(sql.ErrNoRows), 1)
+	RequireEqual(t, ExitCode(WithExitCode(sql.ErrNoRows, 3)), 3)
+	RequireEqual(t, ExitCode(errors.Join(sql.ErrConnDone, WithExitCode(sql.ErrNoRows, 4))), 4)
+	RequireEqual(t, ExitCode(newShutdownError(syscall.SIGINT)), 130)
+	RequireEqual(t, ExitCode(newShutdownError(syscall.SIGTERM)), 143)
+	RequireNil(t, WithExitCode(nil, 3))
+	RequireErrIs(t, WithExitCode(sql.ErrNoRows, 3), sql.ErrNoRows)
+	RequireEqual(t, WithExitCode(sql.ErrNoRows, 3).Error(), sql.ErrNoRows.Error())
+}
+
+func TestMain_exitCode(t *testing.T) {
+	exited := make(chan int, 1)
+	osExit = func(code int) {
+		exited <- code
+	}
+	defer func() {
+		osExit = os.Exit
+	}()
+
+	t.Run("success", func(t *testing.T) {
+		Main(func(context.Context) error {
+			return nil
+		})
+		RequireEqual(t, <-exited, 0)
+	})
+
+	t.Run("custom exit code", func(t *testing.T) {
+		var b BytesBuffer
+		Main(func(context.Context) error {
+			return WithExitCode(sql.ErrNoRows, 3)
+		}, WithShutdownLogger(NewSlog(&b, false, false)))
+		RequireEqual(t, <-exited, 3)
+		RequireTrue(t, strings.Contains(b.String(), `level=ERROR msg=exiting code=3 error="sql: no rows in result set"`))
+	})
+
+	t.Run("zero exit code", func(t *testing.T) {
+		var b BytesBuffer
+		Main(func(context.Context) error {
+			return WithExitCode(sql.ErrNoRows, 0)
+		}, WithShutdownLogger(NewSlog(&b, false, false)))
+		RequireEqual(t, <-exited, 0)
+		RequireTrue(t, strings.Contains(b.String(), `level=INFO msg=exiting code=0 error="sql: no rows in result set"`))
+	})
+
+	t.Run("signal", func(t *testing.T) {
+		hooked := false
+		Main(func(ctx context.Context) error {
+			ctx.(*ShutdownContext).OnShutdown("hook", 0, func(context.Context) error {
+				hooked = true
+				return nil
+			})
+
+			RequireNil(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+			<-ctx.Done()
+			return ctx.Err()
+		}, WithShutdownLogger(NewSlog(&BytesBuffer{}, false, false)))
+		RequireEqual(t, <-exited, 143)
+		RequireTrue(t, hooked)
+	})
+
+	t.Run("hook error", func(t *testing.T) {
+		Main(func(ctx context.Context) error {
+			ctx.(*ShutdownContext).OnShutdown("hook", 0, func(context.Context) error {
+				return sql.ErrConnDone
+			})
+			return nil
+		}, WithShutdownLogger(NewSlog(&BytesBuffer{}, false, false)))
+		RequireEqual(t, <-exited, 1)
+	})
+}
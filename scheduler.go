@@ -0,0 +1,174 @@
+package nstd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrJobPanicked is returned when a scheduled job panics.
+var ErrJobPanicked = errors.New("job panicked")
+
+// OverlapPolicy defines what happens to the activations of a job that occur while it is still running.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips the activations that occur while the job is running.
+	OverlapSkip OverlapPolicy = iota
+	// OverlapWait delays the activations that occur while the job is running until it returns, running it once for all of them.
+	OverlapWait
+)
+
+// String returns a string representation of the OverlapPolicy.
+func (p OverlapPolicy) String() string {
+	switch p {
+	case OverlapSkip:
+		return "skip"
+	case OverlapWait:
+		return "wait"
+	default:
+		return fmt.Sprintf("OverlapPolicy(%d)", int(p))
+	}
+}
+
+// JobSpec describes a job run periodically by a Scheduler.
+type JobSpec struct {
+	_ struct{}
+	// Name identifies the job in logs and errors.
+	Name string
+	// Schedule defines when the job runs, see Every and ParseCron.
+	Schedule Schedule
+	// Run runs the job once, it should return once ctx is done.
+	Run func(ctx context.Context) error
+	// Overlap defines what happens to the activations that occur while the job is running.
+	// a job never runs concurrently with itself.
+	Overlap OverlapPolicy
+	// Timeout bounds every run of the job, zero means no timeout.
+	Timeout time.Duration
+}
+
+// Scheduler runs jobs periodically until its context is done.
+type Scheduler struct {
+	_      struct{}
+	logger *slog.Logger
+	specs  []JobSpec
+}
+
+// NewScheduler creates a new Scheduler that logs failed and skipped runs using logger.
+func NewScheduler(logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		logger: logger,
+	}
+}
+
+// Add adds a job to be run by the Scheduler.
+func (s *Scheduler) Add(spec JobSpec) {
+	s.specs = append(s.specs, spec)
+}
+
+// Run runs every job on its schedule using a Group until ctx is done, usually a ShutdownContext.
+// a failed or panicking run is logged and does not stop the job.
+// once ctx is done no new run starts, and Run waits for the runs in progress, which see ctx done, to return.
+// see Group.Wait for the returned error.
+func (s *Scheduler) Run(ctx context.Context) error {
+	g := NewGroup(ctx)
+	for _, spec := range s.specs {
+		g.Go(spec.Name, s.schedule(spec))
+	}
+
+	return g.Wait()
+}
+
+// schedule returns a function that runs spec.Run on its schedule until ctx is done.
+func (s *Scheduler) schedule(spec JobSpec) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		next := spec.Schedule.Next(time.Now())
+		for !next.IsZero() && ctx.Err() == nil {
+			t := time.NewTimer(time.Until(next) + scheduleJitter(spec.Schedule))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-t.C:
+			}
+
+			s.run(ctx, spec)
+			next = s.next(spec, next)
+		}
+
+		<-ctx.Done()
+		return ctx.Err()
+	}
+}
+
+// scheduleJitter returns the random delay of the next run of schedule, see Every.
+// the jitter only delays the timer, the activations are computed without it so it does not accumulate.
+func scheduleJitter(schedule Schedule) time.Duration {
+	if s, ok := schedule.(jitterSchedule); ok {
+		return s.jitter()
+	}
+
+	return 0
+}
+
+// next returns the activation following prev according to the overlap policy of spec.
+func (s *Scheduler) next(spec JobSpec, prev time.Time) time.Time {
+	now := time.Now()
+	next := spec.Schedule.Next(prev)
+
+	var missed int
+	for !next.IsZero() && next.Before(now) {
+		following := spec.Schedule.Next(next)
+		if spec.Overlap == OverlapWait && (following.IsZero() || !following.Before(now)) {
+			// run once right away for every activation that occurred while running.
+			return next
+		}
+		missed++
+		next = following
+	}
+
+	if missed > 0 {
+		s.logger.Warn("job skipped",
+			slog.String("job", spec.Name),
+			slog.String("overlap", spec.Overlap.String()),
+			slog.Int("skipped", missed),
+		)
+	}
+
+	return next
+}
+
+// run runs spec.Run once and logs its outcome.
+func (s *Scheduler) run(ctx context.Context, spec JobSpec) {
+	if spec.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, spec.Timeout)
+		defer cancel()
+	}
+
+	startedAt := time.Now()
+	err := runJob(ctx, spec.Run)
+	if err != nil {
+		s.logger.Error("job failed",
+			slog.String("job", spec.Name),
+			slog.Duration("elapsed", time.Since(startedAt)),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	s.logger.Debug("job finished", slog.String("job", spec.Name), slog.Duration("elapsed", time.Since(startedAt)))
+}
+
+// runJob calls fn and recovers a panic into an error wrapping ErrJobPanicked.
+func runJob(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrJobPanicked, r)
+		}
+	}()
+
+	return fn(ctx)
+}
@@ -0,0 +1,203 @@
+package nstd_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func TestScheduler(t *testing.T) {
+	t.Run("run on interval", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			s := NewScheduler(NewSlog(&BytesBuffer{}, false, false))
+
+			var runs []time.Duration
+			startedAt := time.Now()
+			s.Add(JobSpec{
+				Name:     "cleanup",
+				Schedule: Every(10*time.Second, 0),
+				Run: func(context.Context) error {
+					runs = append(runs, time.Since(startedAt))
+					return nil
+				},
+			})
+
+			time.AfterFunc(35*time.Second, cancel)
+			RequireErrIs(t, s.Run(ctx), context.Canceled)
+			RequireEqual(t, len(runs), 3)
+			RequireEqual(t, runs[2], 30*time.Second)
+		})
+	})
+
+	t.Run("skip activations while running", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			var b BytesBuffer
+			s := NewScheduler(NewSlog(&b, false, false))
+
+			var runs []time.Duration
+			startedAt := time.Now()
+			s.Add(JobSpec{
+				Name:     "report",
+				Schedule: Every(10*time.Second, 0),
+				Overlap:  OverlapSkip,
+				Run: func(context.Context) error {
+					runs = append(runs, time.Since(startedAt))
+					time.Sleep(25 * time.Second)
+					return nil
+				},
+			})
+
+			time.AfterFunc(45*time.Second, cancel)
+			RequireErrIs(t, s.Run(ctx), context.Canceled)
+			RequireEqual(t, len(runs), 2)
+			RequireEqual(t, runs[0], 10*time.Second)
+			RequireEqual(t, runs[1], 40*time.Second)
+			RequireTrue(t, strings.Contains(b.String(), `msg="job skipped" job=report overlap=skip skipped=2`))
+		})
+	})
+
+	t.Run("delay activations while running", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			s := NewScheduler(NewSlog(&BytesBuffer{}, false, false))
+
+			var runs []time.Duration
+			startedAt := time.Now()
+			s.Add(JobSpec{
+				Name:     "report",
+				Schedule: Every(10*time.Second, 0),
+				Overlap:  OverlapWait,
+				Run: func(context.Context) error {
+					runs = append(runs, time.Since(startedAt))
+					time.Sleep(25 * time.Second)
+					return nil
+				},
+			})
+
+			time.AfterFunc(61*time.Second, cancel)
+			RequireErrIs(t, s.Run(ctx), context.Canceled)
+			RequireEqual(t, len(runs), 3)
+			RequireEqual(t, runs[0], 10*time.Second)
+			RequireEqual(t, runs[1], 35*time.Second)
+			RequireEqual(t, runs[2], 60*time.Second)
+		})
+	})
+
+	t.Run("keep the interval with a jitter", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			s := NewScheduler(NewSlog(&BytesBuffer{}, false, false))
+
+			var runs []time.Duration
+			startedAt := time.Now()
+			s.Add(JobSpec{
+				Name:     "sync",
+				Schedule: Every(10*time.Second, 10*time.Second),
+				Run: func(context.Context) error {
+					runs = append(runs, time.Since(startedAt))
+					return nil
+				},
+			})
+
+			time.AfterFunc(1000*time.Second+time.Millisecond, cancel)
+			RequireErrIs(t, s.Run(ctx), context.Canceled)
+			RequireTrue(t, len(runs) >= 99)
+			for i, run := range runs {
+				activation := time.Duration(i+1) * 10 * time.Second
+				RequireTrue(t, run >= activation && run <= activation+10*time.Second)
+			}
+		})
+	})
+
+	t.Run("log failed runs", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			var b BytesBuffer
+			s := NewScheduler(NewSlog(&b, false, false))
+
+			var runs int
+			s.Add(JobSpec{
+				Name:     "flaky",
+				Schedule: Every(time.Second, 0),
+				Timeout:  500 * time.Millisecond,
+				Run: func(ctx context.Context) error {
+					runs++
+					switch runs {
+					case 1:
+						panic("boom")
+					case 2:
+						<-ctx.Done()
+						return ctx.Err()
+					default:
+						return errors.ErrUnsupported
+					}
+				},
+			})
+
+			time.AfterFunc(3500*time.Millisecond, cancel)
+			RequireErrIs(t, s.Run(ctx), context.Canceled)
+			RequireEqual(t, runs, 3)
+
+			logs := b.String()
+			RequireTrue(t, strings.Contains(logs, `msg="job failed" job=flaky elapsed=0s error="job panicked: boom"`))
+			RequireTrue(t, strings.Contains(logs, `msg="job failed" job=flaky elapsed=500ms error="context deadline exceeded"`))
+			RequireTrue(t, strings.Contains(logs, `msg="job failed" job=flaky elapsed=0s error="unsupported operation"`))
+		})
+	})
+
+	t.Run("wait for runs in progress on shutdown", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := NewShutdownContextWithCause(t.Context(),
+				WithShutdownLogger(NewSlog(&BytesBuffer{}, false, false)),
+				WithSignalSource(&FakeSignalSource{}),
+			)
+			defer cancel(context.Canceled)
+			s := NewScheduler(NewSlog(&BytesBuffer{}, false, false))
+
+			var flushed bool
+			s.Add(JobSpec{
+				Name:     "flush",
+				Schedule: Every(time.Second, 0),
+				Run: func(ctx context.Context) error {
+					<-ctx.Done()
+					time.Sleep(time.Second)
+					flushed = true
+					return nil
+				},
+			})
+
+			time.AfterFunc(1500*time.Millisecond, func() {
+				cancel(context.Canceled)
+			})
+			RequireErrIs(t, s.Run(ctx), context.Canceled)
+			RequireTrue(t, flushed)
+		})
+	})
+
+	t.Run("never run again", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(t.Context(), time.Hour)
+			defer cancel()
+			schedule, err := ParseCron("0 0 30 2 *")
+			RequireNil(t, err)
+
+			s := NewScheduler(NewSlog(&BytesBuffer{}, false, false))
+			s.Add(JobSpec{
+				Name:     "never",
+				Schedule: schedule,
+				Run: func(context.Context) error {
+					t.Fatal("should not run")
+					return nil
+				},
+			})
+			RequireErrIs(t, s.Run(ctx), context.DeadlineExceeded)
+		})
+	})
+}
@@ -2,15 +2,19 @@ package nstd
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"os"
-	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 )
 
 var (
 	_ context.Context = (*ShutdownContext)(nil)
 
-	// gracefulShutdownSignal defines the signals that will trigger a graceful shutdown.
+	// gracefulShutdownSignal defines the default signals that will trigger a graceful shutdown.
+	// it is never mutated, use WithShutdownSignals to configure the signals.
 	gracefulShutdownSignal = []os.Signal{
 		os.Interrupt,
 		syscall.SIGINT,
@@ -19,51 +23,161 @@ var (
 )
 
 // ShutdownContext is a context that will be canceled when syscall.SIGINT or syscall.SIGTERM is received.
+// the signals can be configured using ShutdownOption.
 type ShutdownContext struct {
 	context.Context
-	cancel  context.CancelCauseFunc
-	sigChan chan os.Signal
+	cancel        context.CancelCauseFunc
+	logger        *slog.Logger
+	mu            sync.Mutex
+	hooks         []*shutdownHook
+	delay         time.Duration
+	requested     chan struct{}
+	requestCause  error
+	phaseTimeouts map[ShutdownPhase]time.Duration
+	budget        time.Duration
+	goroutines    *GoroutineSnapshot
+	leakSettle    time.Duration
+	hooksOnce     sync.Once
+	hooksErr      error
+	stop          chan struct{}
+	stopOnce      sync.Once
+	stopped       <-chan struct{}
 }
 
 // NewShutdownContextWithCause creates a new context that will be canceled when syscall.SIGINT or syscall.SIGTERM is received
 // Usually used with s.Wait() for a graceful shutdown
 // ShutdownContext gives more detail on which signal makes the context Done
 // Use NewShutdownContext for simpler method
-func NewShutdownContextWithCause(ctx context.Context) (*ShutdownContext, context.CancelCauseFunc) {
+func NewShutdownContextWithCause(ctx context.Context, opts ...ShutdownOption) (*ShutdownContext, context.CancelCauseFunc) {
 	ctx, cancel := context.WithCancelCause(ctx)
+	o := newShutdownOptions(opts)
+	s := &ShutdownContext{
+		Context:       ctx,
+		cancel:        cancel,
+		logger:        o.logger,
+		stop:          make(chan struct{}),
+		requested:     make(chan struct{}),
+		phaseTimeouts: o.phaseTimeouts,
+		budget:        o.budget,
+	}
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, gracefulShutdownSignal...)
+	o.outstanding = s.outstandingHooks
+	s.stopped = o.notify(ctx, s.stop, func(sig os.Signal) {
+		s.requestShutdown(newShutdownError(sig))
+	})
+	if o.leakReport {
+		s.goroutines = CaptureGoroutines()
+		s.leakSettle = o.leakSettle
+	}
 
-	return &ShutdownContext{
-		Context: ctx,
-		cancel:  cancel,
-		sigChan: sigChan,
-	}, cancel
+	return s, cancel
 }
 
 // Wait waits for the context to be done or an error to be received from the error channel.
 // It returns an error if the context is canceled due to a signal or if an error is received from the error channel.
-// If the context is canceled due to a signal, it returns a shutdownCause error that indicates which signal triggered the shutdown.
+// If the context is canceled due to a signal, it returns a ShutdownError that indicates which signal triggered the shutdown.
+// If an error is received from the error channel, the context is canceled with that error as the cause.
+// Once the context is canceled, it runs the hooks registered using OnShutdown and joins their errors into the returned error.
+// It is safe to call Wait several times and from several goroutines, the hooks only run once.
 func (s *ShutdownContext) Wait(errChan <-chan error) error {
 	select {
-	case sig := <-s.sigChan:
-		signal.Stop(s.sigChan)
-		close(s.sigChan)
-		s.cancel(newShutdownCause(sig))
-		return context.Cause(s)
 	case <-s.Done():
-		return context.Cause(s)
+		return s.shutdown(context.Cause(s))
 	case err := <-errChan:
-		return err
+		return s.shutdown(err)
+	}
+}
+
+// Stop unregisters the signals and disarms the forced exit enabled by WithGracePeriod without canceling the context.
+// the signals are unregistered once Stop returns, they are also unregistered automatically once the context is done,
+// unless WithGracePeriod keeps listening for a second signal.
+// it is safe to call Stop several times.
+func (s *ShutdownContext) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.stopped
+}
+
+// delayShutdown makes a shutdown signal cancel the context at least d after it is received,
+// e.g. to let a failing readiness propagate before the components stop accepting work.
+func (s *ShutdownContext) delayShutdown(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delay = max(s.delay, d)
+}
+
+// requestShutdown cancels the context with cause once the delay set using delayShutdown has elapsed.
+// a shutdown requested again during the delay cancels the context immediately with the first cause.
+func (s *ShutdownContext) requestShutdown(cause error) {
+	s.mu.Lock()
+	first := s.requestCause == nil
+	if first {
+		s.requestCause = cause
+		close(s.requested)
+	}
+	cause = s.requestCause
+	delay := s.delay
+	s.mu.Unlock()
+
+	if !first || delay <= 0 {
+		s.cancel(cause)
+		return
+	}
+
+	s.logger.Info("delaying shutdown", "delay", delay)
+	time.AfterFunc(delay, func() {
+		s.cancel(cause)
+	})
+}
+
+// shuttingDown reports whether a shutdown has been requested or the context is done.
+func (s *ShutdownContext) shuttingDown() bool {
+	select {
+	case <-s.requested:
+		return true
+	default:
+		return s.Err() != nil
 	}
 }
 
+// shutdown cancels the context with err as the cause if it is not canceled yet.
+// it runs the hooks once and joins their errors into err, then reports the leaked goroutines if enabled.
+func (s *ShutdownContext) shutdown(err error) error {
+	s.cancel(err)
+	s.hooksOnce.Do(func() {
+		s.hooksErr = s.runHooks()
+		if s.goroutines != nil {
+			LogLeakedGoroutines(s.logger, s.goroutines, s.leakSettle)
+		}
+	})
+	if s.hooksErr != nil {
+		return errors.Join(err, s.hooksErr)
+	}
+
+	return err
+}
+
 // NewShutdownContext creates a new context that will be canceled when syscall.SIGINT or syscall.SIGTERM is received
 // Usually used with WaitUntil to wait for a graceful shutdown.
 // Use NewShutdownContextWithCause for more detail on which signal triggers the graceful shutdown
-func NewShutdownContext(ctx context.Context) (context.Context, context.CancelFunc) {
-	return signal.NotifyContext(ctx, gracefulShutdownSignal...)
+// Calling the returned cancel function unregisters the signals and disarms the forced exit enabled by WithGracePeriod.
+func NewShutdownContext(ctx context.Context, opts ...ShutdownOption) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := make(chan struct{})
+	stopped := newShutdownOptions(opts).notify(ctx, stop, func(os.Signal) {
+		cancel()
+	})
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			close(stop)
+		})
+		cancel()
+		<-stopped
+	}
 }
 
 // Wait waits until the context is done or an error is received from the error channel.
@@ -0,0 +1,48 @@
+package nstd
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestShutdownContext_Stop(t *testing.T) {
+	t.Run("stop without canceling", func(t *testing.T) {
+		ctx, cancel := NewShutdownContextWithCause(t.Context())
+		defer cancel(context.Canceled)
+
+		ctx.Stop()
+		ctx.Stop()
+		RequireNil(t, ctx.Err())
+		<-ctx.stopped
+	})
+
+	t.Run("unregister once the parent is done", func(t *testing.T) {
+		parent, cancelParent := context.WithCancel(t.Context())
+		ctx, cancel := NewShutdownContextWithCause(parent)
+		defer cancel(context.Canceled)
+
+		cancelParent()
+		<-ctx.stopped
+		RequireEqual(t, ctx.Wait(nil), context.Canceled)
+		ctx.Stop()
+	})
+
+	t.Run("disarm forced exit", func(t *testing.T) {
+		exited := make(chan int, 1)
+		osExit = func(code int) {
+			exited <- code
+		}
+		defer func() {
+			osExit = os.Exit
+		}()
+
+		ctx, cancel := NewShutdownContextWithCause(t.Context(), WithGracePeriod(10*time.Millisecond))
+		cancel(context.Canceled)
+		ctx.Stop()
+
+		time.Sleep(30 * time.Millisecond)
+		RequireEqual(t, len(exited), 0)
+	})
+}
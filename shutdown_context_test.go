@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"os"
+	"sync"
 	"syscall"
 	"testing"
 	"time"
@@ -256,3 +257,55 @@ func TestShutdownContextCombined(t *testing.T) {
 		RequireEqual(t, err, sql.ErrNoRows)
 	})
 }
+
+func TestShutdownContextWaitTwice(t *testing.T) {
+	ctx, cancel := NewShutdownContextWithCause(t.Context())
+	defer cancel(context.Canceled)
+	defer ctx.Stop()
+
+	RequireNil(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			err := ctx.Wait(nil)
+			RequireEqual(t, err.Error(), "context canceled: terminated")
+		})
+	}
+	wg.Wait()
+	RequireEqual(t, ctx.Wait(nil).Error(), "context canceled: terminated")
+}
+
+func TestShutdownContextWithOptions(t *testing.T) {
+	t.Run("shutdown due to custom signal", func(t *testing.T) {
+		ctx, cancel := NewShutdownContextWithCause(t.Context(), WithShutdownSignals(syscall.SIGUSR1))
+		defer cancel(context.Canceled)
+
+		go func() {
+			RequireNil(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+		}()
+
+		err := ctx.Wait(nil)
+		RequireErrIs(t, err, context.Canceled)
+		RequireEqual(t, err.Error(), "context canceled: user defined signal 1")
+
+		sig, ok := SignalFromError(err)
+		RequireTrue(t, ok)
+		RequireEqual(t, sig, os.Signal(syscall.SIGUSR1))
+		var se *ShutdownError
+		RequireErrAs(t, context.Cause(ctx), &se)
+		RequireEqual(t, se.ExitCode(), 128+int(syscall.SIGUSR1))
+	})
+
+	t.Run("forward signal to callback", func(t *testing.T) {
+		forwarded := make(chan os.Signal, 1)
+		ctx, cancel := NewShutdownContext(t.Context(), WithSignalCallback(func(sig os.Signal) {
+			forwarded <- sig
+		}, syscall.SIGUSR2))
+		defer cancel()
+
+		RequireNil(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+		RequireEqual(t, <-forwarded, os.Signal(syscall.SIGUSR2))
+		RequireNil(t, ctx.Err())
+	})
+}
@@ -0,0 +1,150 @@
+package nstd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// hookReturnGrace is the time given to a hook to return once its context is done,
+// so a hook that honours its deadline can report a more specific error than context.DeadlineExceeded.
+const hookReturnGrace = 100 * time.Millisecond
+
+// shutdownHook is a function registered using ShutdownContext.OnShutdown.
+type shutdownHook struct {
+	name    string
+	phase   ShutdownPhase
+	timeout time.Duration
+	fn      func(context.Context) error
+	done    bool
+}
+
+// OnShutdown registers fn to be run by Wait once the context is canceled, e.g. to stop a server or close a database.
+// hooks run during PhaseClose, see OnPhase to run a hook during another phase.
+// the hooks of a phase run sequentially in reverse registration order, each with a fresh non-canceled context carrying the context values.
+// the context given to fn is bounded by timeout, a non-positive timeout means no deadline.
+// a hook that does not return shortly after its timeout is abandoned and reported as context.DeadlineExceeded.
+// hooks registered after Wait has run the hooks are never run.
+func (s *ShutdownContext) OnShutdown(name string, timeout time.Duration, fn func(context.Context) error) {
+	s.OnPhase(PhaseClose, name, timeout, fn)
+}
+
+// runHooks runs the registered hooks phase by phase within the shutdown budget and joins their errors.
+// the hooks of each phase run in reverse registration order, and the timing of every phase that has hooks is logged.
+func (s *ShutdownContext) runHooks() error {
+	s.mu.Lock()
+	hooks := s.hookOrder()
+	s.mu.Unlock()
+
+	cleanup, cancel := NewCleanupContext(s, s.budget)
+	defer cancel()
+
+	var errs []error
+	for _, phase := range shutdownPhases {
+		phaseHooks := slices.DeleteFunc(slices.Clone(hooks), func(h *shutdownHook) bool {
+			return h.phase != phase
+		})
+		if len(phaseHooks) == 0 {
+			continue
+		}
+
+		startedAt := time.Now()
+		phaseErrs := s.runPhase(cleanup, phase, phaseHooks)
+		errs = append(errs, phaseErrs...)
+		s.logger.Info("shutdown phase finished",
+			"phase", phase.String(),
+			"hooks", len(phaseHooks),
+			"failed", len(phaseErrs),
+			"elapsed", time.Since(startedAt),
+		)
+	}
+
+	return errors.Join(errs...)
+}
+
+// runPhase runs hooks sequentially using a context for phase derived from cleanup and returns their errors.
+func (s *ShutdownContext) runPhase(cleanup *CleanupContext, phase ShutdownPhase, hooks []*shutdownHook) []error {
+	ctx, cancel := cleanup.Phase(s.phaseTimeouts[phase])
+	defer cancel()
+
+	var errs []error
+	for _, h := range hooks {
+		startedAt := time.Now()
+		err := ctx.Err()
+		if err == nil {
+			err = h.run(ctx)
+		}
+
+		s.mu.Lock()
+		h.done = true
+		s.mu.Unlock()
+
+		s.logger.Debug("shutdown hook finished", "hook", h.name, "phase", phase.String(), "elapsed", time.Since(startedAt), "error", err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook %q: %w", h.name, err))
+		}
+	}
+
+	return errs
+}
+
+// hookOrder returns the registered hooks in the order they run, it must be called with s.mu held.
+func (s *ShutdownContext) hookOrder() []*shutdownHook {
+	hooks := make([]*shutdownHook, 0, len(s.hooks))
+	for _, phase := range shutdownPhases {
+		for _, h := range slices.Backward(s.hooks) {
+			if h.phase == phase {
+				hooks = append(hooks, h)
+			}
+		}
+	}
+
+	return hooks
+}
+
+// outstandingHooks returns the names of the hooks that have not finished yet, in the order they run.
+func (s *ShutdownContext) outstandingHooks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for _, h := range s.hookOrder() {
+		if !h.done {
+			names = append(names, h.name)
+		}
+	}
+
+	return names
+}
+
+// run calls the hook function and waits until it returns or its timeout has elapsed.
+// once the context is done, the hook is given hookReturnGrace to return its own error before it is abandoned.
+func (h *shutdownHook) run(ctx context.Context) error {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- h.fn(ctx)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+	}
+
+	t := time.NewTimer(hookReturnGrace)
+	defer t.Stop()
+	select {
+	case err := <-errChan:
+		return err
+	case <-t.C:
+		return ctx.Err()
+	}
+}
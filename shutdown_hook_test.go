@@ -0,0 +1,115 @@
+package nstd_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+type hookKey struct{}
+
+func TestShutdownContext_OnShutdown(t *testing.T) {
+	t.Run("run hooks in reverse order", func(t *testing.T) {
+		baseCtx := context.WithValue(t.Context(), hookKey{}, "value")
+		ctx, cancel := NewShutdownContextWithCause(baseCtx)
+		defer cancel(context.Canceled)
+
+		var order []string
+		for _, name := range []string{"db", "queue", "http"} {
+			ctx.OnShutdown(name, time.Second, func(hookCtx context.Context) error {
+				RequireNil(t, hookCtx.Err())
+				RequireEqual(t, hookCtx.Value(hookKey{}).(string), "value")
+				_, ok := hookCtx.Deadline()
+				RequireTrue(t, ok)
+				order = append(order, name)
+				return nil
+			})
+		}
+
+		cancel(context.Canceled)
+		RequireEqual(t, ctx.Wait(nil), context.Canceled)
+		RequireEqual(t, len(order), 3)
+		RequireEqual(t, order[0], "http")
+		RequireEqual(t, order[1], "queue")
+		RequireEqual(t, order[2], "db")
+	})
+
+	t.Run("aggregate hook errors", func(t *testing.T) {
+		ctx, cancel := NewShutdownContextWithCause(t.Context())
+		defer cancel(context.Canceled)
+
+		ctx.OnShutdown("db", 0, func(context.Context) error {
+			return sql.ErrConnDone
+		})
+		ctx.OnShutdown("writer", 0, func(context.Context) error {
+			return io.ErrShortWrite
+		})
+
+		errChan := make(chan error, 1)
+		errChan <- sql.ErrNoRows
+
+		err := ctx.Wait(errChan)
+		RequireErrIs(t, err, sql.ErrNoRows)
+		RequireErrIs(t, err, sql.ErrConnDone)
+		RequireErrIs(t, err, io.ErrShortWrite)
+		RequireErrIs(t, context.Cause(ctx), sql.ErrNoRows)
+		RequireEqual(t, err.Error(), "sql: no rows in result set\n"+
+			`shutdown hook "writer": short write`+"\n"+
+			`shutdown hook "db": sql: connection is already closed`)
+	})
+
+	t.Run("abandon hook after its timeout", func(t *testing.T) {
+		ctx, cancel := NewShutdownContextWithCause(t.Context())
+		defer cancel(context.Canceled)
+
+		block := make(chan struct{})
+		defer close(block)
+		ctx.OnShutdown("stuck", time.Millisecond, func(context.Context) error {
+			<-block
+			return nil
+		})
+
+		cancel(context.Canceled)
+		err := ctx.Wait(nil)
+		RequireErrIs(t, err, context.DeadlineExceeded)
+		RequireErrIs(t, err, context.Canceled)
+	})
+
+	t.Run("report the hook error after its timeout", func(t *testing.T) {
+		ctx, cancel := NewShutdownContextWithCause(t.Context())
+		defer cancel(context.Canceled)
+
+		ctx.OnShutdown("flush", time.Millisecond, func(ctx context.Context) error {
+			<-ctx.Done()
+			return fmt.Errorf("flush 3 pending writes: %w", ctx.Err())
+		})
+
+		cancel(context.Canceled)
+		err := ctx.Wait(nil)
+		RequireErrIs(t, err, context.DeadlineExceeded)
+		RequireTrue(t, strings.Contains(err.Error(), `shutdown hook "flush": flush 3 pending writes: context deadline exceeded`))
+	})
+
+	t.Run("run hooks once", func(t *testing.T) {
+		ctx, cancel := NewShutdownContextWithCause(t.Context())
+		defer cancel(context.Canceled)
+
+		calls := 0
+		ctx.OnShutdown("once", 0, func(context.Context) error {
+			calls++
+			return errors.ErrUnsupported
+		})
+
+		cancel(context.Canceled)
+		RequireErrIs(t, ctx.Wait(nil), errors.ErrUnsupported)
+		RequireErrIs(t, ctx.Wait(nil), errors.ErrUnsupported)
+		RequireEqual(t, calls, 1)
+	})
+}
@@ -0,0 +1,276 @@
+package nstd
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// osExit is used to force the process to exit, it is replaced in tests.
+var osExit = os.Exit
+
+// signalAction defines how a received signal is handled.
+type signalAction int
+
+const (
+	// signalShutdown triggers a graceful shutdown.
+	signalShutdown signalAction = iota
+	// signalIgnore drops the signal, preventing its default behavior.
+	signalIgnore
+	// signalForward forwards the signal to a callback.
+	signalForward
+)
+
+// signalRoute is the behavior of a single signal.
+type signalRoute struct {
+	action signalAction
+	fn     func(os.Signal)
+}
+
+// ShutdownOption configures how a shutdown context reacts to signals.
+type ShutdownOption func(*shutdownOptions)
+
+// shutdownOptions holds the configuration built from ShutdownOption.
+type shutdownOptions struct {
+	routes        map[os.Signal]signalRoute
+	logger        *slog.Logger
+	forceExit     bool
+	gracePeriod   time.Duration
+	finalHook     func()
+	outstanding   func() []string
+	source        SignalSource
+	phaseTimeouts map[ShutdownPhase]time.Duration
+	budget        time.Duration
+	leakReport    bool
+	leakSettle    time.Duration
+}
+
+// newShutdownOptions creates shutdownOptions starting from gracefulShutdownSignal and applies the given opts in order.
+func newShutdownOptions(opts []ShutdownOption) *shutdownOptions {
+	o := &shutdownOptions{
+		routes:        make(map[os.Signal]signalRoute, len(gracefulShutdownSignal)),
+		logger:        slog.Default(),
+		source:        osSignalSource{},
+		phaseTimeouts: make(map[ShutdownPhase]time.Duration),
+	}
+	for _, sig := range gracefulShutdownSignal {
+		o.routes[sig] = signalRoute{action: signalShutdown}
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithShutdownSignals replaces the default signals that trigger a graceful shutdown.
+// signals configured by WithIgnoredSignals or WithSignalCallback are kept unless listed here.
+func WithShutdownSignals(sigs ...os.Signal) ShutdownOption {
+	return func(o *shutdownOptions) {
+		for sig, r := range o.routes {
+			if r.action == signalShutdown {
+				delete(o.routes, sig)
+			}
+		}
+		for _, sig := range sigs {
+			o.routes[sig] = signalRoute{action: signalShutdown}
+		}
+	}
+}
+
+// WithIgnoredSignals receives and drops the given signals, so they neither trigger a shutdown nor their default behavior.
+func WithIgnoredSignals(sigs ...os.Signal) ShutdownOption {
+	return func(o *shutdownOptions) {
+		for _, sig := range sigs {
+			o.routes[sig] = signalRoute{action: signalIgnore}
+		}
+	}
+}
+
+// WithSignalCallback forwards the given signals to fn instead of triggering a shutdown.
+// fn is called sequentially from the goroutine that receives the signals, so it should return quickly.
+func WithSignalCallback(fn func(os.Signal), sigs ...os.Signal) ShutdownOption {
+	return func(o *shutdownOptions) {
+		for _, sig := range sigs {
+			o.routes[sig] = signalRoute{action: signalForward, fn: fn}
+		}
+	}
+}
+
+// WithShutdownLogger sets the logger used to report the shutdown progress, slog.Default() is used by default.
+func WithShutdownLogger(logger *slog.Logger) ShutdownOption {
+	return func(o *shutdownOptions) {
+		o.logger = logger
+	}
+}
+
+// WithGracePeriod enables the forced exit once the shutdown has started.
+// a second shutdown signal exits the process immediately, and if d is positive the process exits once d has elapsed.
+// the exit code is 128 plus the signal number, or 1 if the shutdown was not triggered by a signal.
+// the process is expected to exit by itself once the graceful shutdown completes.
+// for a ShutdownContext, the grace period starts however the context is canceled, including by its cancel function,
+// use ShutdownContext.Stop to disarm it. the cancel function returned by NewShutdownContext disarms it.
+func WithGracePeriod(d time.Duration) ShutdownOption {
+	return func(o *shutdownOptions) {
+		o.forceExit = true
+		o.gracePeriod = d
+	}
+}
+
+// WithForceExitHook sets fn to be called right before the process is forced to exit, e.g. to flush logs.
+// it only takes effect with WithGracePeriod.
+func WithForceExitHook(fn func()) ShutdownOption {
+	return func(o *shutdownOptions) {
+		o.finalHook = fn
+	}
+}
+
+// WithSignalSource sets the source of the signals, the signals received by the process are used by default.
+// use FakeSignalSource to trigger signals deterministically in tests.
+func WithSignalSource(src SignalSource) ShutdownOption {
+	return func(o *shutdownOptions) {
+		o.source = src
+	}
+}
+
+// WithGoroutineLeakReport captures the goroutines alive when the context is created,
+// and logs the goroutines that are still alive once the hooks have finished, see LogLeakedGoroutines.
+// settle is the time given to the goroutines to exit once the hooks have finished.
+func WithGoroutineLeakReport(settle time.Duration) ShutdownOption {
+	return func(o *shutdownOptions) {
+		o.leakReport = true
+		o.leakSettle = settle
+	}
+}
+
+// signals returns every signal that has a route.
+func (o *shutdownOptions) signals() []os.Signal {
+	sigs := make([]os.Signal, 0, len(o.routes))
+	for sig := range o.routes {
+		sigs = append(sigs, sig)
+	}
+
+	return sigs
+}
+
+// notify registers the routed signals and handles them in a separate goroutine until ctx is done or stop is closed.
+// it returns a channel that is closed once the signals are unregistered.
+// it does nothing when no signal is routed, since signal.Notify without signals relays every signal.
+func (o *shutdownOptions) notify(ctx context.Context, stop <-chan struct{}, shutdown func(os.Signal)) <-chan struct{} {
+	done := make(chan struct{})
+	sigs := o.signals()
+	if len(sigs) == 0 {
+		close(done)
+		return done
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	o.source.Notify(sigChan, sigs...)
+
+	go func() {
+		defer close(done)
+		o.route(ctx, stop, sigChan, shutdown)
+	}()
+
+	return done
+}
+
+// route handles the signals received from sigChan according to their routes until stop is closed.
+// it stops receiving signals on sigChan once ctx is done, unless the forced exit is enabled.
+func (o *shutdownOptions) route(ctx context.Context, stop <-chan struct{}, sigChan chan os.Signal, shutdown func(os.Signal)) {
+	defer o.source.Stop(sigChan)
+
+	var first os.Signal
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			if o.forceExit {
+				o.watchForceExit(stop, sigChan, first)
+			}
+			return
+		case sig := <-sigChan:
+			if o.dispatch(sig) {
+				first = sig
+				shutdown(sig)
+			}
+		}
+	}
+}
+
+// dispatch handles the ignored and forwarded signals, it reports whether sig should trigger a shutdown.
+func (o *shutdownOptions) dispatch(sig os.Signal) bool {
+	r := o.routes[sig]
+	switch r.action {
+	case signalShutdown:
+		return true
+	case signalForward:
+		r.fn(sig)
+	case signalIgnore:
+		// NoOp
+	}
+
+	return false
+}
+
+// watchForceExit forces the process to exit when a shutdown signal is received again or the grace period has elapsed.
+// it gives up once stop is closed.
+func (o *shutdownOptions) watchForceExit(stop <-chan struct{}, sigChan chan os.Signal, first os.Signal) {
+	startedAt := time.Now()
+
+	var timeout <-chan time.Time
+	if o.gracePeriod > 0 {
+		t := time.NewTimer(o.gracePeriod)
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case sig := <-sigChan:
+			if o.dispatch(sig) {
+				o.exit("shutdown signal received again", sig, startedAt)
+				return
+			}
+		case <-timeout:
+			o.exit("grace period exceeded", first, startedAt)
+			return
+		}
+	}
+}
+
+// exit logs what is still outstanding, calls the final hook and exits the process with the exit code of sig.
+func (o *shutdownOptions) exit(reason string, sig os.Signal, startedAt time.Time) {
+	attrs := []any{
+		slog.String("reason", reason),
+		slog.Duration("elapsed", time.Since(startedAt)),
+		slog.Int("goroutines", runtime.NumGoroutine()),
+	}
+	if sig != nil {
+		attrs = append(attrs, slog.String("signal", sig.String()))
+	}
+	if o.outstanding != nil {
+		attrs = append(attrs, slog.Any("outstanding", o.outstanding()))
+	}
+	o.logger.Error("forcing shutdown", attrs...)
+
+	if o.finalHook != nil {
+		o.finalHook()
+	}
+	osExit(signalExitCode(sig))
+}
+
+// signalExitCode returns the conventional exit code of a process terminated by sig.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+
+	return 1
+}
@@ -0,0 +1,137 @@
+package nstd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestShutdownOptions(t *testing.T) {
+	t.Run("default signals", func(t *testing.T) {
+		o := newShutdownOptions(nil)
+		RequireEqual(t, o.routes[syscall.SIGINT].action, signalShutdown)
+		RequireEqual(t, o.routes[syscall.SIGTERM].action, signalShutdown)
+	})
+
+	t.Run("replace shutdown signals", func(t *testing.T) {
+		o := newShutdownOptions([]ShutdownOption{
+			WithIgnoredSignals(syscall.SIGHUP),
+			WithShutdownSignals(syscall.SIGTERM, syscall.SIGQUIT),
+		})
+		_, ok := o.routes[syscall.SIGINT]
+		RequireTrue(t, !ok)
+		RequireEqual(t, o.routes[syscall.SIGTERM].action, signalShutdown)
+		RequireEqual(t, o.routes[syscall.SIGQUIT].action, signalShutdown)
+		RequireEqual(t, o.routes[syscall.SIGHUP].action, signalIgnore)
+		RequireEqual(t, len(o.signals()), 3)
+	})
+
+	t.Run("route signals", func(t *testing.T) {
+		forwarded := make(chan os.Signal, 1)
+		o := newShutdownOptions([]ShutdownOption{
+			WithIgnoredSignals(syscall.SIGINT),
+			WithSignalCallback(func(sig os.Signal) {
+				forwarded <- sig
+			}, syscall.SIGHUP),
+		})
+
+		shutdown := make(chan os.Signal, 1)
+		sigChan := make(chan os.Signal)
+		go o.route(t.Context(), nil, sigChan, func(sig os.Signal) {
+			shutdown <- sig
+		})
+
+		sigChan <- syscall.SIGINT
+		sigChan <- syscall.SIGHUP
+		RequireEqual(t, <-forwarded, os.Signal(syscall.SIGHUP))
+		sigChan <- syscall.SIGTERM
+		RequireEqual(t, <-shutdown, os.Signal(syscall.SIGTERM))
+		RequireEqual(t, len(forwarded), 0)
+	})
+}
+
+func TestShutdownOptions_forceExit(t *testing.T) {
+	exited := make(chan int, 1)
+	osExit = func(code int) {
+		exited <- code
+	}
+	defer func() {
+		osExit = os.Exit
+	}()
+
+	t.Run("second signal", func(t *testing.T) {
+		var b BytesBuffer
+		hooked := make(chan struct{})
+		o := newShutdownOptions([]ShutdownOption{
+			WithShutdownLogger(NewSlog(&b, false, false)),
+			WithGracePeriod(0),
+			WithForceExitHook(func() {
+				close(hooked)
+			}),
+		})
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		sigChan := make(chan os.Signal)
+		go o.route(ctx, nil, sigChan, func(os.Signal) {
+			cancel()
+		})
+
+		sigChan <- syscall.SIGTERM
+		sigChan <- syscall.SIGINT
+		RequireEqual(t, <-exited, 130)
+		<-hooked
+		RequireTrue(t, strings.Contains(b.String(), `msg="forcing shutdown" reason="shutdown signal received again"`))
+		RequireTrue(t, strings.Contains(b.String(), `signal=interrupt`))
+	})
+
+	t.Run("grace period exceeded", func(t *testing.T) {
+		var b BytesBuffer
+		o := newShutdownOptions([]ShutdownOption{
+			WithShutdownLogger(NewSlog(&b, false, false)),
+			WithGracePeriod(10 * time.Millisecond),
+		})
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		sigChan := make(chan os.Signal)
+		go o.route(ctx, nil, sigChan, func(os.Signal) {
+			cancel()
+		})
+
+		sigChan <- syscall.SIGTERM
+		RequireEqual(t, <-exited, 143)
+		RequireTrue(t, strings.Contains(b.String(), `reason="grace period exceeded"`))
+	})
+
+	t.Run("grace period exceeded without signal", func(t *testing.T) {
+		o := newShutdownOptions([]ShutdownOption{
+			WithShutdownLogger(NewSlog(&BytesBuffer{}, false, false)),
+			WithGracePeriod(time.Millisecond),
+		})
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		o.route(ctx, nil, make(chan os.Signal), nil)
+		RequireEqual(t, <-exited, 1)
+	})
+
+	t.Run("disarmed by the cancel function of NewShutdownContext", func(t *testing.T) {
+		_, cancel := NewShutdownContext(t.Context(),
+			WithShutdownLogger(NewSlog(&BytesBuffer{}, false, false)),
+			WithGracePeriod(10*time.Millisecond),
+			WithSignalSource(&FakeSignalSource{}),
+		)
+		cancel()
+		cancel()
+
+		select {
+		case code := <-exited:
+			t.Fatalf("exited with code %d", code)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package nstd
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ShutdownPhase is a step of the shutdown, the phases run one after another in their declaration order.
+type ShutdownPhase int
+
+const (
+	// PhaseStopIntake stops accepting new work, e.g. closing listeners or pausing consumers.
+	PhaseStopIntake ShutdownPhase = iota
+	// PhaseDrain waits for the work in flight to complete, e.g. in-flight requests or background tasks.
+	PhaseDrain
+	// PhaseClose releases the resources, e.g. database connections or files, hooks registered using OnShutdown run in this phase.
+	PhaseClose
+)
+
+// shutdownPhases lists every ShutdownPhase in the order they run.
+var shutdownPhases = []ShutdownPhase{PhaseStopIntake, PhaseDrain, PhaseClose}
+
+// String returns a string representation of the ShutdownPhase.
+func (p ShutdownPhase) String() string {
+	switch p {
+	case PhaseStopIntake:
+		return "stop-intake"
+	case PhaseDrain:
+		return "drain"
+	case PhaseClose:
+		return "close"
+	default:
+		return fmt.Sprintf("ShutdownPhase(%d)", int(p))
+	}
+}
+
+// WithShutdownBudget bounds the whole run of the shutdown hooks with d, a non-positive d means no deadline.
+// the phases run on a CleanupContext with this budget, so the time a phase does not use is left for the next ones.
+// the hooks that have not run once the budget is exhausted are skipped and reported as context.DeadlineExceeded.
+func WithShutdownBudget(d time.Duration) ShutdownOption {
+	return func(o *shutdownOptions) {
+		o.budget = d
+	}
+}
+
+// WithPhaseTimeout bounds the context shared by the hooks of phase with d within the shutdown budget,
+// see CleanupContext.Phase, a non-positive d gives the phase the whole remaining budget.
+// the hooks that have not run once the phase has timed out are skipped and reported as context.DeadlineExceeded.
+func WithPhaseTimeout(phase ShutdownPhase, d time.Duration) ShutdownOption {
+	return func(o *shutdownOptions) {
+		o.phaseTimeouts[phase] = d
+	}
+}
+
+// OnPhase registers fn to be run during phase once the context is canceled, see OnShutdown.
+// the context given to fn is bounded by timeout, by the phase timeout set using WithPhaseTimeout
+// and by the shutdown budget set using WithShutdownBudget.
+// it panics if phase is unknown.
+func (s *ShutdownContext) OnPhase(phase ShutdownPhase, name string, timeout time.Duration, fn func(context.Context) error) {
+	if phase < PhaseStopIntake || phase > PhaseClose {
+		panic(fmt.Sprintf("nstd: unknown shutdown phase %q", phase))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks = append(s.hooks, &shutdownHook{
+		name:    name,
+		phase:   phase,
+		timeout: timeout,
+		fn:      fn,
+	})
+}
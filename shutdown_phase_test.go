@@ -0,0 +1,143 @@
+package nstd_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func TestShutdownContext_OnPhase(t *testing.T) {
+	t.Run("run phases in order", func(t *testing.T) {
+		var b BytesBuffer
+		ctx, cancel := NewShutdownContextWithCause(t.Context(), WithShutdownLogger(NewSlog(&b, false, false)))
+		defer cancel(context.Canceled)
+
+		var order []string
+		record := func(name string) func(context.Context) error {
+			return func(context.Context) error {
+				order = append(order, name)
+				return nil
+			}
+		}
+		ctx.OnShutdown("db", 0, record("db"))
+		ctx.OnPhase(PhaseDrain, "requests", 0, record("requests"))
+		ctx.OnPhase(PhaseStopIntake, "listener", 0, record("listener"))
+		ctx.OnPhase(PhaseDrain, "tasks", 0, record("tasks"))
+		ctx.OnPhase(PhaseClose, "cache", 0, record("cache"))
+
+		cancel(context.Canceled)
+		RequireEqual(t, ctx.Wait(nil), context.Canceled)
+		RequireEqual(t, strings.Join(order, ","), "listener,tasks,requests,cache,db")
+
+		logs := b.String()
+		RequireTrue(t, strings.Contains(logs, `msg="shutdown phase finished" phase=stop-intake hooks=1 failed=0`))
+		RequireTrue(t, strings.Contains(logs, `msg="shutdown phase finished" phase=drain hooks=2 failed=0`))
+		RequireTrue(t, strings.Contains(logs, `msg="shutdown phase finished" phase=close hooks=2 failed=0`))
+	})
+
+	t.Run("skip empty phases", func(t *testing.T) {
+		var b BytesBuffer
+		ctx, cancel := NewShutdownContextWithCause(t.Context(), WithShutdownLogger(NewSlog(&b, false, false)))
+		defer cancel(context.Canceled)
+		ctx.OnShutdown("db", 0, func(context.Context) error {
+			return nil
+		})
+
+		cancel(context.Canceled)
+		RequireEqual(t, ctx.Wait(nil), context.Canceled)
+		RequireEqual(t, strings.Count(b.String(), "shutdown phase finished"), 1)
+	})
+
+	t.Run("bound the phase with its timeout", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := NewShutdownContextWithCause(t.Context(),
+				WithShutdownLogger(NewSlog(&BytesBuffer{}, false, false)),
+				WithPhaseTimeout(PhaseDrain, 5*time.Second),
+			)
+			defer cancel(context.Canceled)
+
+			var skipped, closed bool
+			ctx.OnPhase(PhaseDrain, "skipped", 0, func(context.Context) error {
+				skipped = true
+				return nil
+			})
+			ctx.OnPhase(PhaseDrain, "requests", 0, func(ctx context.Context) error {
+				deadline, ok := ctx.Deadline()
+				RequireTrue(t, ok)
+				RequireEqual(t, deadline, time.Now().Add(5*time.Second))
+				<-ctx.Done()
+				return ctx.Err()
+			})
+			ctx.OnShutdown("db", 0, func(ctx context.Context) error {
+				_, ok := ctx.Deadline()
+				RequireTrue(t, !ok)
+				closed = true
+				return nil
+			})
+
+			cancel(context.Canceled)
+			err := ctx.Wait(nil)
+			RequireErrIs(t, err, context.DeadlineExceeded)
+			RequireTrue(t, strings.Contains(err.Error(), `shutdown hook "requests": context deadline exceeded`))
+			RequireTrue(t, strings.Contains(err.Error(), `shutdown hook "skipped": context deadline exceeded`))
+			RequireTrue(t, !skipped)
+			RequireTrue(t, closed)
+		})
+	})
+
+	t.Run("bound the phases with the shutdown budget", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := NewShutdownContextWithCause(t.Context(),
+				WithShutdownLogger(NewSlog(&BytesBuffer{}, false, false)),
+				WithShutdownBudget(10*time.Second),
+				WithPhaseTimeout(PhaseDrain, 8*time.Second),
+			)
+			defer cancel(context.Canceled)
+
+			var skipped bool
+			ctx.OnShutdown("skipped", 0, func(context.Context) error {
+				skipped = true
+				return nil
+			})
+			ctx.OnShutdown("db", 0, func(ctx context.Context) error {
+				deadline, ok := ctx.Deadline()
+				RequireTrue(t, ok)
+				RequireEqual(t, deadline, time.Now().Add(4*time.Second))
+				<-ctx.Done()
+				return ctx.Err()
+			})
+			ctx.OnPhase(PhaseDrain, "requests", 0, func(ctx context.Context) error {
+				deadline, ok := ctx.Deadline()
+				RequireTrue(t, ok)
+				RequireEqual(t, deadline, time.Now().Add(8*time.Second))
+				time.Sleep(6 * time.Second)
+				return nil
+			})
+
+			startedAt := time.Now()
+			cancel(context.Canceled)
+			err := ctx.Wait(nil)
+			RequireEqual(t, time.Since(startedAt), 10*time.Second)
+			RequireTrue(t, strings.Contains(err.Error(), `shutdown hook "db": context deadline exceeded`))
+			RequireTrue(t, strings.Contains(err.Error(), `shutdown hook "skipped": context deadline exceeded`))
+			RequireTrue(t, !skipped)
+		})
+	})
+
+	t.Run("panic on unknown phase", func(t *testing.T) {
+		defer func() {
+			i := recover()
+			RequireNotNil(t, i)
+			RequireEqual(t, i.(string), `nstd: unknown shutdown phase "ShutdownPhase(3)"`)
+		}()
+		ctx, cancel := NewShutdownContextWithCause(t.Context())
+		defer cancel(context.Canceled)
+		ctx.OnPhase(ShutdownPhase(3), "unknown", 0, func(context.Context) error {
+			return nil
+		})
+	})
+}
@@ -0,0 +1,128 @@
+package nstd
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+)
+
+// SignalRouter dispatches non-terminating signals, e.g. SIGHUP or SIGUSR1, to handlers alongside a ShutdownContext.
+// the signals handled by a SignalRouter should not be configured as shutdown signals.
+type SignalRouter struct {
+	_        struct{}
+	mu       sync.Mutex
+	logger   *slog.Logger
+	source   SignalSource
+	handlers map[os.Signal]func(ctx context.Context, sig os.Signal)
+}
+
+// NewSignalRouter creates a new SignalRouter that logs using logger.
+// src is the source of the signals, nil means the signals received by the process.
+func NewSignalRouter(logger *slog.Logger, src SignalSource) *SignalRouter {
+	if src == nil {
+		src = osSignalSource{}
+	}
+
+	return &SignalRouter{
+		logger:   logger,
+		source:   src,
+		handlers: make(map[os.Signal]func(context.Context, os.Signal)),
+	}
+}
+
+// Handle registers fn to be called when sig is received, replacing the previous handler of sig.
+// handlers are called sequentially from the goroutine running Run, so a slow handler delays the next signals.
+func (r *SignalRouter) Handle(sig os.Signal, fn func(ctx context.Context, sig os.Signal)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[sig] = fn
+}
+
+// HandleReload registers fn to be called when syscall.SIGHUP is received, e.g. to reload the configuration.
+// the reload and its result are logged.
+func (r *SignalRouter) HandleReload(fn func(ctx context.Context) error) {
+	r.Handle(syscall.SIGHUP, func(ctx context.Context, sig os.Signal) {
+		r.logger.Info("reloading", slog.String("signal", sig.String()))
+		if err := fn(ctx); err != nil {
+			r.logger.Error("reload failed", slog.String("signal", sig.String()), slog.Any("error", err))
+			return
+		}
+		r.logger.Info("reloaded", slog.String("signal", sig.String()))
+	})
+}
+
+// HandleDiagnostics logs the goroutine stacks and runtime stats using LogDiagnostics when
+// syscall.SIGUSR1 (unix only) or syscall.SIGQUIT is received, without exiting the process.
+func (r *SignalRouter) HandleDiagnostics() {
+	for _, sig := range diagnosticSignals {
+		r.Handle(sig, func(ctx context.Context, sig os.Signal) {
+			LogDiagnostics(ctx, r.logger, slog.String("signal", sig.String()))
+		})
+	}
+}
+
+// Run dispatches the received signals to their handlers until ctx is done, it always returns nil.
+// only the signals with a handler at the time Run is called are received.
+func (r *SignalRouter) Run(ctx context.Context) error {
+	r.mu.Lock()
+	handlers := make(map[os.Signal]func(context.Context, os.Signal), len(r.handlers))
+	sigs := make([]os.Signal, 0, len(r.handlers))
+	for sig, fn := range r.handlers {
+		handlers[sig] = fn
+		sigs = append(sigs, sig)
+	}
+	r.mu.Unlock()
+
+	if len(sigs) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	r.source.Notify(sigChan, sigs...)
+	defer r.source.Stop(sigChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig := <-sigChan:
+			handlers[sig](ctx, sig)
+		}
+	}
+}
+
+// LogDiagnostics logs the stacks of every goroutine and the runtime stats at info level using logger.
+func LogDiagnostics(ctx context.Context, logger *slog.Logger, attrs ...slog.Attr) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	attrs = append(attrs,
+		slog.Int("goroutines", runtime.NumGoroutine()),
+		slog.Group("memory",
+			slog.Uint64("heap_alloc", m.HeapAlloc),
+			slog.Uint64("heap_sys", m.HeapSys),
+			slog.Uint64("heap_objects", m.HeapObjects),
+			slog.Uint64("sys", m.Sys),
+			slog.Uint64("num_gc", uint64(m.NumGC)),
+			slog.Uint64("pause_total_ns", m.PauseTotalNs),
+		),
+		slog.String("stacks", string(goroutineStacks())),
+	)
+	logger.LogAttrs(ctx, slog.LevelInfo, "diagnostics", attrs...)
+}
+
+// goroutineStacks returns the stacks of every goroutine formatted by runtime.Stack.
+func goroutineStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package nstd
+
+import (
+	"os"
+	"syscall"
+)
+
+// diagnosticSignals are the signals handled by SignalRouter.HandleDiagnostics, SIGUSR1 only exists on unix.
+var diagnosticSignals = []os.Signal{syscall.SIGQUIT}
@@ -0,0 +1,80 @@
+package nstd_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"syscall"
+	"testing"
+	"testing/synctest"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func TestSignalRouter(t *testing.T) {
+	t.Run("reload on syscall.SIGHUP", func(t *testing.T) {
+		t.Parallel()
+		synctest.Test(t, func(t *testing.T) {
+			var b BytesBuffer
+			var src FakeSignalSource
+			r := NewSignalRouter(NewSlog(&b, false, false), &src)
+
+			reloads := 0
+			r.HandleReload(func(context.Context) error {
+				reloads++
+				if reloads == 2 {
+					return sql.ErrConnDone
+				}
+				return nil
+			})
+
+			ctx, cancel := context.WithCancel(t.Context())
+			errChan := make(chan error, 1)
+			go func() {
+				errChan <- r.Run(ctx)
+			}()
+			synctest.Wait()
+
+			RequireEqual(t, src.Send(syscall.SIGHUP), 1)
+			RequireEqual(t, src.Send(syscall.SIGHUP), 1)
+			synctest.Wait()
+			RequireEqual(t, reloads, 2)
+			RequireEqual(t, src.Send(syscall.SIGUSR1), 0)
+
+			cancel()
+			RequireNil(t, <-errChan)
+			RequireEqual(t, src.Send(syscall.SIGHUP), 0)
+
+			logs := b.String()
+			RequireEqual(t, strings.Count(logs, `msg=reloading signal=hangup`), 2)
+			RequireEqual(t, strings.Count(logs, `msg=reloaded signal=hangup`), 1)
+			RequireTrue(t, strings.Contains(logs, `msg="reload failed" signal=hangup error="sql: connection is already closed"`))
+		})
+	})
+
+	t.Run("dump diagnostics on syscall.SIGUSR1", func(t *testing.T) {
+		t.Parallel()
+		synctest.Test(t, func(t *testing.T) {
+			var b BytesBuffer
+			var src FakeSignalSource
+			r := NewSignalRouter(NewSlog(&b, false, true), &src)
+			r.HandleDiagnostics()
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+			go func() {
+				_ = r.Run(ctx)
+			}()
+			synctest.Wait()
+
+			RequireEqual(t, src.Send(syscall.SIGUSR1), 1)
+			synctest.Wait()
+
+			logs := b.String()
+			RequireTrue(t, strings.Contains(logs, `"msg":"diagnostics","signal":"user defined signal 1","goroutines":`))
+			RequireTrue(t, strings.Contains(logs, `"memory":{"heap_alloc":`))
+			RequireTrue(t, strings.Contains(logs, `goroutine `))
+			RequireTrue(t, strings.Contains(logs, `SignalRouter).Run`))
+		})
+	})
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package nstd
+
+import (
+	"os"
+	"syscall"
+)
+
+// diagnosticSignals are the signals handled by SignalRouter.HandleDiagnostics.
+var diagnosticSignals = []os.Signal{syscall.SIGUSR1, syscall.SIGQUIT}
@@ -0,0 +1,100 @@
+package nstd
+
+import (
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+)
+
+var (
+	_ SignalSource = osSignalSource{}
+	_ SignalSource = (*FakeSignalSource)(nil)
+)
+
+// SignalSource delivers incoming signals to channels, it mirrors signal.Notify and signal.Stop.
+type SignalSource interface {
+	// Notify relays the given signals, or every signal if none is given, to c.
+	Notify(c chan<- os.Signal, sig ...os.Signal)
+	// Stop stops relaying signals to c.
+	Stop(c chan<- os.Signal)
+}
+
+// osSignalSource is the default SignalSource relaying the signals received by the process using os/signal.
+type osSignalSource struct{}
+
+// Notify wraps signal.Notify.
+func (osSignalSource) Notify(c chan<- os.Signal, sig ...os.Signal) {
+	signal.Notify(c, sig...)
+}
+
+// Stop wraps signal.Stop.
+func (osSignalSource) Stop(c chan<- os.Signal) {
+	signal.Stop(c)
+}
+
+// fakeSubscription is a channel notified by a FakeSignalSource.
+type fakeSubscription struct {
+	sigs    []os.Signal
+	stopped chan struct{}
+}
+
+// FakeSignalSource is a SignalSource that only relays the signals given to Send, without touching the process signals.
+// only use this in test
+type FakeSignalSource struct {
+	mu   sync.Mutex
+	subs map[chan<- os.Signal]*fakeSubscription
+}
+
+// Notify relays the given signals, or every signal if none is given, to c.
+func (f *FakeSignalSource) Notify(c chan<- os.Signal, sig ...os.Signal) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.subs == nil {
+		f.subs = make(map[chan<- os.Signal]*fakeSubscription)
+	}
+	if sub, ok := f.subs[c]; ok {
+		sub.sigs = append(sub.sigs, sig...)
+		return
+	}
+	f.subs[c] = &fakeSubscription{
+		sigs:    sig,
+		stopped: make(chan struct{}),
+	}
+}
+
+// Stop stops relaying signals to c.
+func (f *FakeSignalSource) Stop(c chan<- os.Signal) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if sub, ok := f.subs[c]; ok {
+		close(sub.stopped)
+		delete(f.subs, c)
+	}
+}
+
+// Send relays sig to every channel notified for it and returns the number of channels it was delivered to.
+// unlike os/signal, it blocks until every channel receives sig or is stopped, so no signal is dropped.
+func (f *FakeSignalSource) Send(sig os.Signal) int {
+	f.mu.Lock()
+	targets := make(map[chan<- os.Signal]*fakeSubscription)
+	for c, sub := range f.subs {
+		if len(sub.sigs) == 0 || slices.Contains(sub.sigs, sig) {
+			targets[c] = sub
+		}
+	}
+	f.mu.Unlock()
+
+	delivered := 0
+	for c, sub := range targets {
+		select {
+		case c <- sig:
+			delivered++
+		case <-sub.stopped:
+		}
+	}
+
+	return delivered
+}
@@ -0,0 +1,84 @@
+package nstd_test
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func TestFakeSignalSource(t *testing.T) {
+	t.Parallel()
+
+	var src FakeSignalSource
+	c := make(chan os.Signal, 1)
+	all := make(chan os.Signal, 1)
+	src.Notify(c, syscall.SIGTERM)
+	src.Notify(all)
+
+	RequireEqual(t, src.Send(syscall.SIGTERM), 2)
+	RequireEqual(t, <-c, os.Signal(syscall.SIGTERM))
+	RequireEqual(t, <-all, os.Signal(syscall.SIGTERM))
+
+	RequireEqual(t, src.Send(syscall.SIGHUP), 1)
+	RequireEqual(t, <-all, os.Signal(syscall.SIGHUP))
+
+	src.Stop(c)
+	src.Stop(all)
+	RequireEqual(t, src.Send(syscall.SIGTERM), 0)
+}
+
+func TestShutdownContextWithSignalSource(t *testing.T) {
+	t.Run("shutdown due to fake syscall.SIGTERM", func(t *testing.T) {
+		t.Parallel()
+		synctest.Test(t, func(t *testing.T) {
+			var src FakeSignalSource
+			ctx, cancel := NewShutdownContextWithCause(t.Context(), WithSignalSource(&src))
+			defer cancel(context.Canceled)
+
+			var hookedAt time.Time
+			ctx.OnShutdown("hook", time.Second, func(context.Context) error {
+				time.Sleep(time.Millisecond)
+				hookedAt = time.Now()
+				return nil
+			})
+
+			sentAt := time.Now()
+			RequireEqual(t, src.Send(syscall.SIGTERM), 1)
+
+			err := ctx.Wait(nil)
+			RequireEqual(t, err.Error(), "context canceled: terminated")
+			var se *ShutdownError
+			RequireErrAs(t, err, &se)
+			RequireEqual(t, se.ReceivedAt, sentAt)
+			RequireEqual(t, se.ExitCode(), 143)
+			RequireEqual(t, hookedAt.Sub(sentAt), time.Millisecond)
+
+			synctest.Wait()
+			RequireEqual(t, src.Send(syscall.SIGTERM), 0)
+		})
+	})
+
+	t.Run("ignore fake syscall.SIGINT", func(t *testing.T) {
+		t.Parallel()
+		synctest.Test(t, func(t *testing.T) {
+			var src FakeSignalSource
+			ctx, cancel := NewShutdownContext(t.Context(),
+				WithSignalSource(&src),
+				WithIgnoredSignals(syscall.SIGINT),
+			)
+			defer cancel()
+
+			RequireEqual(t, src.Send(syscall.SIGINT), 1)
+			synctest.Wait()
+			RequireNil(t, ctx.Err())
+
+			RequireEqual(t, src.Send(syscall.SIGTERM), 1)
+			RequireEqual(t, Wait(ctx, nil), context.Canceled)
+		})
+	})
+}
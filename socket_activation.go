@@ -0,0 +1,134 @@
+package nstd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// listenFDsStart is the first file descriptor passed by systemd socket activation.
+	listenFDsStart = 3
+	// upgradePPIDEnv replaces LISTEN_PID for file descriptors passed by an Upgrader, since the child pid is unknown before exec.
+	upgradePPIDEnv = "NSTD_UPGRADE_PPID"
+)
+
+// inheritedFDs holds the file descriptors passed to the process, parsed from the environment on first use.
+var inheritedFDs = sync.OnceValue(func() *listenFDs {
+	fds := newListenFDs(os.Getenv, os.Getpid(), os.Getppid(), listenFDsStart)
+	for _, key := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES", upgradePPIDEnv} {
+		_ = os.Unsetenv(key)
+	}
+
+	return fds
+})
+
+// namedFile is an inherited file descriptor with its name from LISTEN_FDNAMES.
+type namedFile struct {
+	name string
+	file *os.File
+}
+
+// listenFDs is the set of inherited file descriptors not taken yet.
+type listenFDs struct {
+	mu    sync.Mutex
+	files []namedFile
+}
+
+// newListenFDs parses LISTEN_PID, LISTEN_FDS and LISTEN_FDNAMES using getenv.
+// the file descriptors are only used if LISTEN_PID matches pid, or if NSTD_UPGRADE_PPID matches ppid when passed by an Upgrader.
+// they are numbered consecutively from start, file descriptors without a name are named "unknown", like systemd does.
+// every file descriptor is marked close-on-exec, so the ones never taken are not leaked to child processes.
+func newListenFDs(getenv func(string) string, pid, ppid, start int) *listenFDs {
+	fds := &listenFDs{}
+	if getenv("LISTEN_PID") != strconv.Itoa(pid) && getenv(upgradePPIDEnv) != strconv.Itoa(ppid) {
+		return fds
+	}
+
+	n, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return fds
+	}
+
+	var names []string
+	if v := getenv("LISTEN_FDNAMES"); v != "" {
+		names = strings.Split(v, ":")
+	}
+
+	for i := range n {
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		closeOnExec(start + i)
+		fds.files = append(fds.files, namedFile{
+			name: name,
+			file: os.NewFile(uintptr(start+i), name),
+		})
+	}
+
+	return fds
+}
+
+// take removes and returns the first inherited file named name, or nil if there is none.
+func (l *listenFDs) take(name string) *os.File {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, f := range l.files {
+		if f.name == name {
+			l.files = append(l.files[:i], l.files[i+1:]...)
+			return f.file
+		}
+	}
+
+	return nil
+}
+
+// listener converts the inherited file named name into a net.Listener, or listens on addr if there is none.
+func (l *listenFDs) listener(name, network, addr string) (net.Listener, error) {
+	f := l.take(name)
+	if f == nil {
+		return net.Listen(network, addr)
+	}
+	defer f.Close()
+
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherited listener %q: %w", name, err)
+	}
+
+	return ln, nil
+}
+
+// packetConn converts the inherited file named name into a net.PacketConn, or listens on addr if there is none.
+func (l *listenFDs) packetConn(name, network, addr string) (net.PacketConn, error) {
+	f := l.take(name)
+	if f == nil {
+		return net.ListenPacket(network, addr)
+	}
+	defer f.Close()
+
+	conn, err := net.FilePacketConn(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherited packet conn %q: %w", name, err)
+	}
+
+	return conn, nil
+}
+
+// ActivatedListener returns the stream listener named name passed by systemd socket activation using
+// LISTEN_FDS, LISTEN_PID and LISTEN_FDNAMES, unnamed file descriptors are named "unknown".
+// every inherited file descriptor is returned once, if none is left for name it listens on network and addr instead.
+// the environment variables are unset on first use, so they are not passed to child processes.
+func ActivatedListener(name, network, addr string) (net.Listener, error) {
+	return inheritedFDs().listener(name, network, addr)
+}
+
+// ActivatedPacketConn is like ActivatedListener for datagram sockets, it listens using net.ListenPacket if none is left for name.
+func ActivatedPacketConn(name, network, addr string) (net.PacketConn, error) {
+	return inheritedFDs().packetConn(name, network, addr)
+}
@@ -0,0 +1,169 @@
+//go:build unix
+
+package nstd
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func activationEnv(fds int, names string) func(string) string {
+	env := map[string]string{
+		"LISTEN_PID":     strconv.Itoa(os.Getpid()),
+		"LISTEN_FDS":     strconv.Itoa(fds),
+		"LISTEN_FDNAMES": names,
+	}
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+// dupFDs duplicates the file descriptors of files to consecutive unused numbers and closes files,
+// so the duplicates are only closed by their new owner. it returns the first number.
+func dupFDs(t *testing.T, files ...*os.File) int {
+	t.Helper()
+
+	start := 100
+	for i := 0; i < len(files); {
+		var st syscall.Stat_t
+		if err := syscall.Fstat(start+i, &st); !errors.Is(err, syscall.EBADF) {
+			start += i + 1
+			i = 0
+			continue
+		}
+		i++
+	}
+
+	for i, f := range files {
+		// F_DUPFD returns the lowest unused number from start+i, which is start+i itself.
+		fd, _, errno := syscall.Syscall(syscall.SYS_FCNTL, f.Fd(), syscall.F_DUPFD, uintptr(start+i))
+		if errno != 0 {
+			t.Fatal(errno)
+		}
+		RequireEqual(t, int(fd), start+i)
+		RequireNil(t, f.Close())
+	}
+
+	return start
+}
+
+// socketFile returns the file of a new TCP listener, the listener itself is closed.
+func socketFile(t *testing.T) *os.File {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	RequireNil(t, err)
+	defer ln.Close()
+	f, err := ln.(*net.TCPListener).File()
+	RequireNil(t, err)
+	return f
+}
+
+// isCloseOnExec reports whether fd is marked close-on-exec.
+func isCloseOnExec(t *testing.T, fd uintptr) bool {
+	t.Helper()
+	flags, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, syscall.F_GETFD, 0)
+	if errno != 0 {
+		t.Fatal(errno)
+	}
+	return flags&syscall.FD_CLOEXEC != 0
+}
+
+func TestListenFDs(t *testing.T) {
+	t.Run("ignore other process", func(t *testing.T) {
+		fds := newListenFDs(func(key string) string {
+			switch key {
+			case "LISTEN_PID":
+				return strconv.Itoa(os.Getpid() + 1)
+			case upgradePPIDEnv:
+				return strconv.Itoa(os.Getppid() + 1)
+			}
+			return "1"
+		}, os.Getpid(), os.Getppid(), listenFDsStart)
+		RequireEqual(t, len(fds.files), 0)
+	})
+
+	t.Run("accept file descriptors passed by an upgrade", func(t *testing.T) {
+		fds := newListenFDs(func(key string) string {
+			switch key {
+			case upgradePPIDEnv:
+				return strconv.Itoa(os.Getppid())
+			case "LISTEN_FDS":
+				return "1"
+			}
+			return ""
+		}, os.Getpid(), os.Getppid(), dupFDs(t, socketFile(t)))
+		RequireEqual(t, len(fds.files), 1)
+		RequireNil(t, fds.files[0].file.Close())
+	})
+
+	t.Run("name unnamed file descriptors", func(t *testing.T) {
+		start := dupFDs(t, socketFile(t), socketFile(t))
+		fds := newListenFDs(activationEnv(2, "http"), os.Getpid(), os.Getppid(), start)
+		RequireEqual(t, len(fds.files), 2)
+		RequireEqual(t, fds.files[0].name, "http")
+		RequireEqual(t, fds.files[1].name, "unknown")
+		RequireEqual(t, fds.files[1].file.Fd(), uintptr(start+1))
+		for _, f := range fds.files {
+			RequireNil(t, f.file.Close())
+		}
+	})
+
+	t.Run("mark file descriptors close-on-exec", func(t *testing.T) {
+		fd := dupFDs(t, socketFile(t))
+		RequireTrue(t, !isCloseOnExec(t, uintptr(fd)))
+
+		fds := newListenFDs(activationEnv(1, "http"), os.Getpid(), os.Getppid(), fd)
+		RequireEqual(t, len(fds.files), 1)
+		RequireTrue(t, isCloseOnExec(t, fds.files[0].file.Fd()))
+		RequireNil(t, fds.files[0].file.Close())
+	})
+
+	t.Run("inherit listener", func(t *testing.T) {
+		orig, err := net.Listen("tcp", "127.0.0.1:0")
+		RequireNil(t, err)
+		defer orig.Close()
+		f, err := orig.(*net.TCPListener).File()
+		RequireNil(t, err)
+
+		fds := newListenFDs(activationEnv(1, "http"), os.Getpid(), os.Getppid(), dupFDs(t, f))
+		ln, err := fds.listener("http", "tcp", "127.0.0.1:0")
+		RequireNil(t, err)
+		defer ln.Close()
+		RequireEqual(t, ln.Addr().String(), orig.Addr().String())
+
+		fallback, err := fds.listener("http", "tcp", "127.0.0.1:0")
+		RequireNil(t, err)
+		defer fallback.Close()
+		RequireTrue(t, fallback.Addr().String() != orig.Addr().String())
+	})
+
+	t.Run("inherit packet conn", func(t *testing.T) {
+		orig, err := net.ListenPacket("udp", "127.0.0.1:0")
+		RequireNil(t, err)
+		defer orig.Close()
+		f, err := orig.(*net.UDPConn).File()
+		RequireNil(t, err)
+
+		fds := newListenFDs(activationEnv(1, ""), os.Getpid(), os.Getppid(), dupFDs(t, f))
+		conn, err := fds.packetConn("unknown", "udp", "127.0.0.1:0")
+		RequireNil(t, err)
+		defer conn.Close()
+		RequireEqual(t, conn.LocalAddr().String(), orig.LocalAddr().String())
+	})
+
+	t.Run("fail on wrong socket type", func(t *testing.T) {
+		orig, err := net.ListenPacket("udp", "127.0.0.1:0")
+		RequireNil(t, err)
+		defer orig.Close()
+		f, err := orig.(*net.UDPConn).File()
+		RequireNil(t, err)
+
+		fds := newListenFDs(activationEnv(1, "http"), os.Getpid(), os.Getppid(), dupFDs(t, f))
+		_, err = fds.listener("http", "tcp", "127.0.0.1:0")
+		RequireNotNil(t, err)
+	})
+}
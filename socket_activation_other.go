@@ -0,0 +1,6 @@
+//go:build !unix
+
+package nstd
+
+// closeOnExec is a no-op, file descriptors are only inherited on unix.
+func closeOnExec(int) {}
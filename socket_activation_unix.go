@@ -0,0 +1,10 @@
+//go:build unix
+
+package nstd
+
+import "syscall"
+
+// closeOnExec marks the inherited file descriptor fd close-on-exec, so it is not leaked to child processes unless passed explicitly.
+func closeOnExec(fd int) {
+	syscall.CloseOnExec(fd)
+}
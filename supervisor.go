@@ -0,0 +1,180 @@
+package nstd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrRestartLimitExceeded is returned when a supervised component exceeds SupervisorSpec.MaxRestarts.
+var ErrRestartLimitExceeded = errors.New("restart limit exceeded")
+
+const (
+	// defaultBackoffMin is the delay before the first restart when SupervisorSpec.BackoffMin is not set.
+	defaultBackoffMin = 100 * time.Millisecond
+	// defaultBackoffMax is the maximum delay between restarts when SupervisorSpec.BackoffMax is not set.
+	defaultBackoffMax = 30 * time.Second
+)
+
+// RestartPolicy defines when a supervised component is restarted.
+type RestartPolicy int
+
+const (
+	// RestartNever never restarts the component.
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure restarts the component when it returns an error.
+	RestartOnFailure
+	// RestartAlways restarts the component whenever it returns.
+	RestartAlways
+)
+
+// String returns a string representation of the RestartPolicy.
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartNever:
+		return "never"
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartAlways:
+		return "always"
+	default:
+		return fmt.Sprintf("RestartPolicy(%d)", int(p))
+	}
+}
+
+// SupervisorSpec describes a component run by a Supervisor.
+type SupervisorSpec struct {
+	_ struct{}
+	// Name identifies the component in logs and errors.
+	Name string
+	// Run runs the component until ctx is done or the component fails.
+	Run func(ctx context.Context) error
+	// Policy defines when the component is restarted.
+	Policy RestartPolicy
+	// MaxRestarts is the maximum number of restarts within Window, zero means unlimited.
+	MaxRestarts int
+	// Window is the period in which restarts are counted, zero means restarts are counted forever.
+	Window time.Duration
+	// BackoffMin is the delay before the first restart, 100ms if not set.
+	// the delay doubles on every restart within Window, with jitter, up to BackoffMax.
+	BackoffMin time.Duration
+	// BackoffMax is the maximum delay between restarts, 30s if not set.
+	BackoffMax time.Duration
+}
+
+// Supervisor runs components and restarts them according to their restart policy.
+type Supervisor struct {
+	_      struct{}
+	logger *slog.Logger
+	specs  []SupervisorSpec
+}
+
+// NewSupervisor creates a new Supervisor that logs every restart using logger.
+func NewSupervisor(logger *slog.Logger) *Supervisor {
+	return &Supervisor{
+		logger: logger,
+	}
+}
+
+// Add adds a component to be run by the Supervisor.
+func (s *Supervisor) Add(spec SupervisorSpec) {
+	s.specs = append(s.specs, spec)
+}
+
+// Run runs every component using a Group until ctx is done or a component stops being restarted with an error.
+// supervision stops without restarting once ctx is done, see Group.Wait for the returned error.
+func (s *Supervisor) Run(ctx context.Context) error {
+	g := NewGroup(ctx)
+	for _, spec := range s.specs {
+		g.Go(spec.Name, s.supervise(spec))
+	}
+
+	return g.Wait()
+}
+
+// supervise returns a function that runs spec.Run and restarts it according to spec.
+func (s *Supervisor) supervise(spec SupervisorSpec) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var restarts []time.Time
+		for {
+			err := spec.Run(ctx)
+			if ctx.Err() != nil || !spec.shouldRestart(err) {
+				return err
+			}
+
+			now := time.Now()
+			restarts = spec.recentRestarts(restarts, now)
+			if spec.MaxRestarts > 0 && len(restarts) >= spec.MaxRestarts {
+				if err == nil {
+					return fmt.Errorf("%w after %d restarts", ErrRestartLimitExceeded, len(restarts))
+				}
+				return fmt.Errorf("%w after %d restarts: %w", ErrRestartLimitExceeded, len(restarts), err)
+			}
+			restarts = append(restarts, now)
+
+			delay := spec.backoff(len(restarts))
+			s.logger.Warn("restarting component",
+				slog.String("component", spec.Name),
+				slog.String("policy", spec.Policy.String()),
+				slog.Int("restarts", len(restarts)),
+				slog.Duration("backoff", delay),
+				slog.Any("error", err),
+			)
+
+			t := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-t.C:
+			}
+		}
+	}
+}
+
+// shouldRestart reports whether the component should be restarted after it returned err.
+func (spec SupervisorSpec) shouldRestart(err error) bool {
+	switch spec.Policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// recentRestarts drops the restarts that happened before the window ending at now.
+func (spec SupervisorSpec) recentRestarts(restarts []time.Time, now time.Time) []time.Time {
+	if spec.Window <= 0 {
+		return restarts
+	}
+
+	for len(restarts) > 0 && now.Sub(restarts[0]) > spec.Window {
+		restarts = restarts[1:]
+	}
+
+	return restarts
+}
+
+// backoff returns the exponential delay before the n-th restart with jitter between half and the full delay.
+func (spec SupervisorSpec) backoff(n int) time.Duration {
+	lo, hi := spec.BackoffMin, spec.BackoffMax
+	if lo <= 0 {
+		lo = defaultBackoffMin
+	}
+	if hi <= 0 {
+		hi = defaultBackoffMax
+	}
+
+	delay := lo
+	for i := 1; i < n && delay < hi; i++ {
+		delay *= 2
+	}
+	delay = min(delay, max(lo, hi))
+
+	return delay/2 + rand.N(delay/2+1)
+}
@@ -0,0 +1,127 @@
+package nstd_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func TestSupervisor(t *testing.T) {
+	t.Run("restart on failure until success", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var b BytesBuffer
+			s := NewSupervisor(NewSlog(&b, false, false))
+
+			runs := 0
+			s.Add(SupervisorSpec{
+				Name:   "consumer",
+				Policy: RestartOnFailure,
+				Run: func(context.Context) error {
+					runs++
+					if runs < 3 {
+						return sql.ErrConnDone
+					}
+					return nil
+				},
+			})
+
+			startedAt := time.Now()
+			RequireNil(t, s.Run(t.Context()))
+			RequireEqual(t, runs, 3)
+			RequireTrue(t, time.Since(startedAt) >= 50*time.Millisecond+100*time.Millisecond)
+			RequireEqual(t, strings.Count(b.String(), `msg="restarting component" component=consumer policy=on-failure`), 2)
+		})
+	})
+
+	t.Run("never restart", func(t *testing.T) {
+		s := NewSupervisor(NewSlog(&BytesBuffer{}, false, false))
+		runs := 0
+		s.Add(SupervisorSpec{
+			Name:   "job",
+			Policy: RestartNever,
+			Run: func(context.Context) error {
+				runs++
+				return sql.ErrConnDone
+			},
+		})
+
+		err := s.Run(t.Context())
+		RequireErrIs(t, err, sql.ErrConnDone)
+		RequireEqual(t, runs, 1)
+	})
+
+	t.Run("exceed max restarts within window", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			s := NewSupervisor(NewSlog(&BytesBuffer{}, false, false))
+			runs := 0
+			s.Add(SupervisorSpec{
+				Name:        "consumer",
+				Policy:      RestartAlways,
+				MaxRestarts: 2,
+				Window:      time.Minute,
+				BackoffMin:  time.Second,
+				BackoffMax:  time.Second,
+				Run: func(context.Context) error {
+					runs++
+					return nil
+				},
+			})
+
+			err := s.Run(t.Context())
+			RequireErrIs(t, err, ErrRestartLimitExceeded)
+			RequireEqual(t, err.Error(), `component "consumer": restart limit exceeded after 2 restarts`)
+			RequireEqual(t, runs, 3)
+		})
+	})
+
+	t.Run("restarts outside the window are forgotten", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			s := NewSupervisor(NewSlog(&BytesBuffer{}, false, false))
+			runs := 0
+			s.Add(SupervisorSpec{
+				Name:        "consumer",
+				Policy:      RestartOnFailure,
+				MaxRestarts: 1,
+				Window:      time.Second,
+				Run: func(context.Context) error {
+					runs++
+					if runs == 4 {
+						return nil
+					}
+					time.Sleep(2 * time.Second)
+					return sql.ErrConnDone
+				},
+			})
+
+			RequireNil(t, s.Run(t.Context()))
+			RequireEqual(t, runs, 4)
+		})
+	})
+
+	t.Run("stop supervising on shutdown", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			s := NewSupervisor(NewSlog(&BytesBuffer{}, false, false))
+			s.Add(SupervisorSpec{
+				Name:       "consumer",
+				Policy:     RestartOnFailure,
+				BackoffMin: time.Hour,
+				Run: func(context.Context) error {
+					return sql.ErrConnDone
+				},
+			})
+
+			go func() {
+				time.Sleep(time.Minute)
+				cancel()
+			}()
+
+			RequireEqual(t, s.Run(ctx), context.Canceled)
+		})
+	})
+}
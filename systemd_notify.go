@@ -0,0 +1,115 @@
+package nstd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SystemdNotifier sends state updates to systemd using the sd_notify datagram protocol on NOTIFY_SOCKET.
+// every method is a no-op if the process is not started by systemd with Type=notify.
+type SystemdNotifier struct {
+	_                struct{}
+	addr             *net.UnixAddr
+	watchdogInterval time.Duration
+}
+
+// NewSystemdNotifier creates a new SystemdNotifier from the NOTIFY_SOCKET, WATCHDOG_USEC and WATCHDOG_PID environment variables.
+func NewSystemdNotifier() *SystemdNotifier {
+	n := &SystemdNotifier{}
+
+	if socket := os.Getenv("NOTIFY_SOCKET"); socket != "" {
+		// a leading '@' means an abstract socket, which is handled by the net package.
+		n.addr = &net.UnixAddr{Name: socket, Net: "unixgram"}
+	}
+
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return n
+	}
+	if usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64); err == nil && usec > 0 {
+		n.watchdogInterval = time.Duration(usec) * time.Microsecond / 2
+	}
+
+	return n
+}
+
+// Enabled reports whether NOTIFY_SOCKET is set.
+func (n *SystemdNotifier) Enabled() bool {
+	return n.addr != nil
+}
+
+// WatchdogInterval returns the interval between the watchdog pings, half of WATCHDOG_USEC, or 0 if the watchdog is disabled.
+func (n *SystemdNotifier) WatchdogInterval() time.Duration {
+	return n.watchdogInterval
+}
+
+// Notify sends the given newline-separated state assignments, e.g. "READY=1", in a single datagram.
+func (n *SystemdNotifier) Notify(states ...string) error {
+	if n.addr == nil {
+		return nil
+	}
+
+	conn, err := net.DialUnix(n.addr.Net, nil, n.addr)
+	if err != nil {
+		return fmt.Errorf("sd_notify: %w", err)
+	}
+
+	_, err = conn.Write([]byte(strings.Join(states, "\n")))
+	if closeErr := conn.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("sd_notify: %w", err)
+	}
+
+	return nil
+}
+
+// Ready tells systemd that the startup is finished.
+func (n *SystemdNotifier) Ready() error {
+	return n.Notify("READY=1")
+}
+
+// Stopping tells systemd that the graceful shutdown has started.
+func (n *SystemdNotifier) Stopping() error {
+	return n.Notify("STOPPING=1")
+}
+
+// Status sends a free-form status shown by systemctl status.
+func (n *SystemdNotifier) Status(status string) error {
+	return n.Notify("STATUS=" + status)
+}
+
+// Bind sends STOPPING=1 as soon as s is canceled.
+func (n *SystemdNotifier) Bind(s *ShutdownContext) {
+	context.AfterFunc(s, func() {
+		_ = n.Stopping()
+	})
+}
+
+// Watchdog sends WATCHDOG=1 every WatchdogInterval until ctx is done.
+// it returns nil once ctx is done or immediately if the watchdog is disabled, or the first error failing to ping.
+func (n *SystemdNotifier) Watchdog(ctx context.Context) error {
+	if n.addr == nil || n.watchdogInterval <= 0 {
+		return nil
+	}
+
+	t := time.NewTicker(n.watchdogInterval)
+	defer t.Stop()
+
+	for {
+		if err := n.Notify("WATCHDOG=1"); err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
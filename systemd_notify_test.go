@@ -0,0 +1,100 @@
+package nstd_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func listenNotifySocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "nstd")
+	RequireNil(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	socket := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	RequireNil(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	t.Setenv("NOTIFY_SOCKET", socket)
+
+	return conn
+}
+
+func readNotify(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+	RequireNil(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	RequireNil(t, err)
+	return string(buf[:n])
+}
+
+func TestSystemdNotifier(t *testing.T) {
+	t.Run("disabled without NOTIFY_SOCKET", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", "")
+		n := NewSystemdNotifier()
+		RequireTrue(t, !n.Enabled())
+		RequireNil(t, n.Ready())
+		RequireNil(t, n.Watchdog(t.Context()))
+	})
+
+	t.Run("send states", func(t *testing.T) {
+		conn := listenNotifySocket(t)
+		n := NewSystemdNotifier()
+		RequireTrue(t, n.Enabled())
+
+		RequireNil(t, n.Ready())
+		RequireEqual(t, readNotify(t, conn), "READY=1")
+		RequireNil(t, n.Status("serving"))
+		RequireEqual(t, readNotify(t, conn), "STATUS=serving")
+		RequireNil(t, n.Notify("RELOADING=1", "MONOTONIC_USEC=1"))
+		RequireEqual(t, readNotify(t, conn), "RELOADING=1\nMONOTONIC_USEC=1")
+	})
+
+	t.Run("send STOPPING=1 on shutdown", func(t *testing.T) {
+		conn := listenNotifySocket(t)
+		ctx, cancel := NewShutdownContextWithCause(t.Context())
+		defer cancel(context.Canceled)
+
+		NewSystemdNotifier().Bind(ctx)
+		cancel(context.Canceled)
+		RequireEqual(t, readNotify(t, conn), "STOPPING=1")
+	})
+
+	t.Run("ping watchdog", func(t *testing.T) {
+		conn := listenNotifySocket(t)
+		t.Setenv("WATCHDOG_USEC", "20000")
+		t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+
+		n := NewSystemdNotifier()
+		RequireEqual(t, n.WatchdogInterval(), 10*time.Millisecond)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- n.Watchdog(ctx)
+		}()
+
+		RequireEqual(t, readNotify(t, conn), "WATCHDOG=1")
+		RequireEqual(t, readNotify(t, conn), "WATCHDOG=1")
+		cancel()
+		RequireNil(t, <-errChan)
+	})
+
+	t.Run("watchdog for another process", func(t *testing.T) {
+		t.Setenv("WATCHDOG_USEC", "20000")
+		t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+		RequireEqual(t, NewSystemdNotifier().WatchdogInterval(), time.Duration(0))
+	})
+}
@@ -0,0 +1,153 @@
+package nstd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrTrackerClosed is returned when a task is started after the TaskTracker stopped accepting tasks.
+var ErrTrackerClosed = errors.New("task tracker closed")
+
+// trackedTask is a task started using TaskTracker.Go.
+type trackedTask struct {
+	name      string
+	startedAt time.Time
+}
+
+// TaskTracker runs fire-and-forget tasks, e.g. sending an email after a request, and lets the shutdown wait for them.
+type TaskTracker struct {
+	_      struct{}
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	logger *slog.Logger
+	mu     sync.Mutex
+	closed bool
+	tasks  map[*trackedTask]struct{}
+	wg     sync.WaitGroup
+}
+
+// NewTaskTracker creates a new TaskTracker that refuses new tasks once ctx, usually a ShutdownContext, is done.
+// failed and unfinished tasks are logged using logger.
+func NewTaskTracker(ctx context.Context, logger *slog.Logger) *TaskTracker {
+	tasksCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	return &TaskTracker{
+		parent: ctx,
+		ctx:    tasksCtx,
+		cancel: cancel,
+		logger: logger,
+		tasks:  make(map[*trackedTask]struct{}),
+	}
+}
+
+// Go runs fn in a separate goroutine and tracks it until it returns.
+// fn receives a context carrying the values of the parent context that is not canceled when the parent context is done,
+// it is only canceled once Wait gives up on the unfinished tasks.
+// an error returned by fn is logged.
+// it returns ErrTrackerClosed without running fn once the parent context is done or Wait has been called.
+func (t *TaskTracker) Go(name string, fn func(ctx context.Context) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed || t.parent.Err() != nil {
+		return fmt.Errorf("task %q: %w", name, ErrTrackerClosed)
+	}
+
+	task := &trackedTask{name: name, startedAt: time.Now()}
+	t.tasks[task] = struct{}{}
+	t.wg.Go(func() {
+		err := fn(t.ctx)
+
+		t.mu.Lock()
+		delete(t.tasks, task)
+		t.mu.Unlock()
+
+		if err != nil {
+			t.logger.Error("task failed",
+				slog.String("task", name),
+				slog.Duration("elapsed", time.Since(task.startedAt)),
+				slog.Any("error", err),
+			)
+		}
+	})
+
+	return nil
+}
+
+// Len returns the number of tasks in flight.
+func (t *TaskTracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.tasks)
+}
+
+// Wait stops accepting new tasks and waits for the tasks in flight to return until ctx is done.
+// if ctx is done first, the context of the unfinished tasks is canceled and they are logged and returned
+// as joined errors, each one identifying the task and its age.
+// it has the signature of a shutdown hook, see Bind.
+func (t *TaskTracker) Wait(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	defer t.cancel()
+	unfinished := t.unfinished()
+	if len(unfinished) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	errs := make([]error, 0, len(unfinished))
+	names := make([]string, 0, len(unfinished))
+	for _, task := range unfinished {
+		errs = append(errs, fmt.Errorf("task %q running for %s: %w", task.name, now.Sub(task.startedAt), ctx.Err()))
+		names = append(names, task.name)
+	}
+	t.logger.Warn("tasks did not finish",
+		slog.Int("count", len(unfinished)),
+		slog.Any("tasks", names),
+		slog.Duration("oldest", now.Sub(unfinished[0].startedAt)),
+	)
+
+	return errors.Join(errs...)
+}
+
+// Bind registers Wait as a shutdown hook of s during PhaseDrain, waiting for the tasks in flight up to timeout.
+func (t *TaskTracker) Bind(s *ShutdownContext, timeout time.Duration) {
+	s.OnPhase(PhaseDrain, "task tracker", timeout, t.Wait)
+}
+
+// unfinished returns the tasks in flight, oldest first.
+func (t *TaskTracker) unfinished() []*trackedTask {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tasks := make([]*trackedTask, 0, len(t.tasks))
+	for task := range t.tasks {
+		tasks = append(tasks, task)
+	}
+	slices.SortFunc(tasks, func(a, b *trackedTask) int {
+		return a.startedAt.Compare(b.startedAt)
+	})
+
+	return tasks
+}
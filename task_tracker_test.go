@@ -0,0 +1,138 @@
+package nstd_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	. "github.com/clavinjune/nstd"
+)
+
+func TestTaskTracker(t *testing.T) {
+	t.Run("wait for tasks in flight", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			tr := NewTaskTracker(ctx, NewSlog(&BytesBuffer{}, false, false))
+
+			var sent bool
+			RequireNil(t, tr.Go("send email", func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				RequireNil(t, ctx.Err())
+				sent = true
+				return nil
+			}))
+			RequireEqual(t, tr.Len(), 1)
+
+			cancel()
+			waitCtx, waitCancel := context.WithTimeout(t.Context(), time.Minute)
+			defer waitCancel()
+			RequireNil(t, tr.Wait(waitCtx))
+			RequireTrue(t, sent)
+			RequireEqual(t, tr.Len(), 0)
+		})
+	})
+
+	t.Run("refuse tasks once the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		tr := NewTaskTracker(ctx, NewSlog(&BytesBuffer{}, false, false))
+		cancel()
+
+		err := tr.Go("send email", func(context.Context) error {
+			t.Fatal("should not run")
+			return nil
+		})
+		RequireErrIs(t, err, ErrTrackerClosed)
+		RequireEqual(t, err.Error(), `task "send email": task tracker closed`)
+	})
+
+	t.Run("refuse tasks once waiting", func(t *testing.T) {
+		tr := NewTaskTracker(t.Context(), NewSlog(&BytesBuffer{}, false, false))
+		RequireNil(t, tr.Wait(t.Context()))
+		RequireErrIs(t, tr.Go("send email", func(context.Context) error {
+			return nil
+		}), ErrTrackerClosed)
+	})
+
+	t.Run("report unfinished tasks", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			var b BytesBuffer
+			tr := NewTaskTracker(t.Context(), NewSlog(&b, false, false))
+
+			RequireNil(t, tr.Go("stuck", func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}))
+			RequireNil(t, tr.Go("quick", func(context.Context) error {
+				return nil
+			}))
+
+			waitCtx, waitCancel := context.WithTimeout(t.Context(), time.Second)
+			defer waitCancel()
+			err := tr.Wait(waitCtx)
+			RequireErrIs(t, err, context.DeadlineExceeded)
+			RequireEqual(t, err.Error(), `task "stuck" running for 1s: context deadline exceeded`)
+
+			synctest.Wait()
+			RequireEqual(t, tr.Len(), 0)
+			RequireTrue(t, strings.Contains(b.String(), `msg="tasks did not finish" count=1 tasks=[stuck] oldest=1s`))
+		})
+	})
+
+	t.Run("log failed tasks", func(t *testing.T) {
+		var b BytesBuffer
+		tr := NewTaskTracker(t.Context(), NewSlog(&b, false, false))
+		RequireNil(t, tr.Go("send email", func(context.Context) error {
+			return errors.ErrUnsupported
+		}))
+
+		RequireNil(t, tr.Wait(t.Context()))
+		RequireTrue(t, strings.Contains(b.String(), `msg="task failed" task="send email"`))
+		RequireTrue(t, strings.Contains(b.String(), `error="unsupported operation"`))
+	})
+
+	t.Run("wait on shutdown", func(t *testing.T) {
+		ctx, cancel := NewShutdownContextWithCause(t.Context())
+		defer cancel(context.Canceled)
+		tr := NewTaskTracker(ctx, NewSlog(&BytesBuffer{}, false, false))
+		tr.Bind(ctx, time.Second)
+
+		finished := make(chan struct{})
+		RequireNil(t, tr.Go("send email", func(context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			close(finished)
+			return nil
+		}))
+
+		cancel(context.Canceled)
+		RequireEqual(t, ctx.Wait(nil), context.Canceled)
+		select {
+		case <-finished:
+		default:
+			t.Fatal("task did not finish before shutdown returned")
+		}
+	})
+	t.Run("report unfinished tasks on shutdown", func(t *testing.T) {
+		synctest.Test(t, func(t *testing.T) {
+			ctx, cancel := NewShutdownContextWithCause(t.Context(),
+				WithShutdownLogger(NewSlog(&BytesBuffer{}, false, false)),
+				WithSignalSource(&FakeSignalSource{}),
+			)
+			defer cancel(context.Canceled)
+			tr := NewTaskTracker(ctx, NewSlog(&BytesBuffer{}, false, false))
+			tr.Bind(ctx, time.Second)
+
+			RequireNil(t, tr.Go("stuck", func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			}))
+
+			cancel(context.Canceled)
+			err := ctx.Wait(nil)
+			RequireErrIs(t, err, context.DeadlineExceeded)
+			RequireTrue(t, strings.Contains(err.Error(), `shutdown hook "task tracker": task "stuck" running for 1s: context deadline exceeded`))
+		})
+	})
+}
@@ -0,0 +1,276 @@
+//go:build unix
+
+package nstd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// upgradeReadyFDEnv holds the file descriptor the child of an Upgrader writes to once it is ready.
+	upgradeReadyFDEnv = "NSTD_UPGRADE_READY_FD"
+	// defaultUpgradeReadyTimeout is used when UpgraderOptions.ReadyTimeout is not set.
+	defaultUpgradeReadyTimeout = time.Minute
+)
+
+// ErrUpgraded is the cause of a ShutdownContext canceled because a new process took over its listeners.
+// its exit code is 0, see ExitCode.
+var ErrUpgraded = WithExitCode(errors.New("process upgraded"), 0)
+
+// filer is implemented by listeners whose file descriptor can be passed to a child process.
+type filer interface {
+	File() (*os.File, error)
+}
+
+// upgradeListener is a listener created by an Upgrader.
+type upgradeListener struct {
+	name     string
+	listener filer
+}
+
+// UpgraderOptions configures an Upgrader.
+type UpgraderOptions struct {
+	_ struct{}
+	// Logger reports the upgrades, the ShutdownContext logger is used if not set.
+	Logger *slog.Logger
+	// ReadyTimeout bounds the wait for the new process to call Ready, one minute if not set.
+	ReadyTimeout time.Duration
+	// Source is the source of syscall.SIGUSR2, the signals received by the process are used if not set.
+	Source SignalSource
+}
+
+// Upgrader performs zero-downtime binary upgrades by handing the listeners over to a re-executed process.
+// on syscall.SIGUSR2 the process re-executes itself passing its listeners, waits for the new process to be ready,
+// then cancels the ShutdownContext with ErrUpgraded so the old process drains and exits.
+type Upgrader struct {
+	_         struct{}
+	s         *ShutdownContext
+	opts      UpgraderOptions
+	mu        sync.Mutex
+	listeners []upgradeListener
+	upgrading bool
+	command   func() *exec.Cmd
+}
+
+// NewUpgrader creates a new Upgrader that cancels s once an upgrade succeeds.
+func NewUpgrader(s *ShutdownContext, opts UpgraderOptions) *Upgrader {
+	if opts.Logger == nil {
+		opts.Logger = s.logger
+	}
+	if opts.ReadyTimeout <= 0 {
+		opts.ReadyTimeout = defaultUpgradeReadyTimeout
+	}
+	if opts.Source == nil {
+		opts.Source = osSignalSource{}
+	}
+
+	return &Upgrader{
+		s:    s,
+		opts: opts,
+		command: func() *exec.Cmd {
+			cmd := exec.Command(os.Args[0], os.Args[1:]...)
+			if exe, err := os.Executable(); err == nil {
+				cmd.Path = exe
+			}
+			return cmd
+		},
+	}
+}
+
+// Listener returns the listener named name inherited from the previous process, see ActivatedListener,
+// or listens on network and addr. the listener is passed to the next process on upgrade.
+func (u *Upgrader) Listener(name, network, addr string) (net.Listener, error) {
+	ln, err := ActivatedListener(name, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ok := ln.(filer)
+	if !ok {
+		_ = ln.Close()
+		return nil, fmt.Errorf("listener %q: %T cannot be passed to another process", name, ln)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.listeners = append(u.listeners, upgradeListener{name: name, listener: f})
+
+	return ln, nil
+}
+
+// Ready tells the previous process that this process is ready to serve, so it can drain and exit.
+// it does nothing if the process was not started by an Upgrader.
+func (u *Upgrader) Ready() error {
+	v, ok := os.LookupEnv(upgradeReadyFDEnv)
+	if !ok {
+		return nil
+	}
+	_ = os.Unsetenv(upgradeReadyFDEnv)
+
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("upgrade ready fd: %w", err)
+	}
+
+	f := os.NewFile(uintptr(fd), "upgrade-ready")
+	defer f.Close()
+	if _, err := f.Write([]byte{1}); err != nil {
+		return fmt.Errorf("upgrade ready: %w", err)
+	}
+
+	return nil
+}
+
+// Run upgrades the process on every syscall.SIGUSR2 until ctx is done, a failed upgrade is logged and the process keeps serving.
+func (u *Upgrader) Run(ctx context.Context) error {
+	r := NewSignalRouter(u.opts.Logger, u.opts.Source)
+	r.Handle(syscall.SIGUSR2, func(context.Context, os.Signal) {
+		if err := u.Upgrade(); err != nil {
+			u.opts.Logger.Error("upgrade failed", slog.Any("error", err))
+		}
+	})
+
+	return r.Run(ctx)
+}
+
+// Upgrade re-executes the process passing its listeners and waits for the new process to call Ready.
+// once ready, the ShutdownContext is canceled with ErrUpgraded.
+// if the new process exits or is not ready within the timeout, it is killed and the current process keeps serving.
+// it fails if another upgrade is in progress.
+func (u *Upgrader) Upgrade() error {
+	files, names, err := u.begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+
+		u.mu.Lock()
+		u.upgrading = false
+		u.mu.Unlock()
+	}()
+
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("upgrade: %w", err)
+	}
+	defer ready.Close()
+	files = append(files, readyW)
+
+	cmd := u.command()
+	if cmd.Stdin == nil && cmd.Stdout == nil && cmd.Stderr == nil {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	}
+	cmd.ExtraFiles = files
+	cmd.Env = append(upgradeEnviron(cmd.Environ()),
+		"LISTEN_FDS="+strconv.Itoa(len(names)),
+		"LISTEN_FDNAMES="+strings.Join(names, ":"),
+		upgradePPIDEnv+"="+strconv.Itoa(os.Getpid()),
+		upgradeReadyFDEnv+"="+strconv.Itoa(listenFDsStart+len(names)),
+	)
+
+	startedAt := time.Now()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("upgrade: %w", err)
+	}
+	_ = readyW.Close()
+	files = files[:len(files)-1]
+
+	if err := u.waitReady(ready); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("upgrade: new process %d: %w", cmd.Process.Pid, err)
+	}
+
+	// the new process is reaped once it exits, it usually outlives the current process.
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	u.opts.Logger.Info("upgraded",
+		slog.Int("pid", cmd.Process.Pid),
+		slog.Any("listeners", names),
+		slog.Duration("elapsed", time.Since(startedAt)),
+	)
+	u.s.cancel(ErrUpgraded)
+
+	return nil
+}
+
+// begin marks an upgrade in progress and returns the files and names of the listeners to pass to the new process,
+// the caller must close the files and clear u.upgrading once done.
+// the listeners are snapshotted so u.mu is not held while waiting for the new process.
+func (u *Upgrader) begin() ([]*os.File, []string, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.upgrading {
+		return nil, nil, errors.New("upgrade: already in progress")
+	}
+	if u.s.Err() != nil {
+		return nil, nil, fmt.Errorf("upgrade: %w", context.Cause(u.s))
+	}
+
+	files := make([]*os.File, 0, len(u.listeners)+1)
+	names := make([]string, 0, len(u.listeners))
+	for _, l := range u.listeners {
+		f, err := l.listener.File()
+		if err != nil {
+			for _, f := range files {
+				_ = f.Close()
+			}
+			return nil, nil, fmt.Errorf("upgrade listener %q: %w", l.name, err)
+		}
+		files = append(files, f)
+		names = append(names, l.name)
+	}
+	u.upgrading = true
+
+	return files, names, nil
+}
+
+// waitReady waits for the new process to write to ready within the ready timeout.
+func (u *Upgrader) waitReady(ready *os.File) error {
+	if err := ready.SetReadDeadline(time.Now().Add(u.opts.ReadyTimeout)); err != nil {
+		return err
+	}
+
+	_, err := ready.Read(make([]byte, 1))
+	switch {
+	case errors.Is(err, io.EOF):
+		return errors.New("exited before being ready")
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return fmt.Errorf("not ready within %s", u.opts.ReadyTimeout)
+	}
+
+	return err
+}
+
+// upgradeEnviron removes the variables describing inherited file descriptors from env.
+func upgradeEnviron(env []string) []string {
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		switch key {
+		case "LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES", upgradePPIDEnv, upgradeReadyFDEnv:
+			continue
+		}
+		out = append(out, kv)
+	}
+
+	return out
+}
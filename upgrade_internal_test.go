@@ -0,0 +1,151 @@
+//go:build unix
+
+package nstd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestUpgraderChild is run as the new process by TestUpgrader.
+func TestUpgraderChild(t *testing.T) {
+	mode := os.Getenv("NSTD_TEST_UPGRADE_CHILD")
+	if mode == "" {
+		t.Skip("only run as the child of TestUpgrader")
+	}
+
+	ctx, cancel := NewShutdownContextWithCause(t.Context())
+	defer cancel(context.Canceled)
+	u := NewUpgrader(ctx, UpgraderOptions{})
+
+	ln, err := u.Listener("http", "tcp", "127.0.0.1:0")
+	RequireNil(t, err)
+	defer ln.Close()
+	RequireEqual(t, ln.Addr().String(), os.Getenv("NSTD_TEST_UPGRADE_ADDR"))
+
+	switch mode {
+	case "ready":
+		RequireNil(t, u.Ready())
+	case "hang":
+		time.Sleep(time.Minute)
+	}
+}
+
+// newTestUpgrader creates an Upgrader starting TestUpgraderChild in mode, the started commands are sent to the returned channel.
+func newTestUpgrader(t *testing.T, mode string, opts UpgraderOptions) (*ShutdownContext, *Upgrader, <-chan *exec.Cmd) {
+	t.Helper()
+	ctx, cancel := NewShutdownContextWithCause(t.Context())
+	t.Cleanup(func() {
+		cancel(context.Canceled)
+	})
+
+	opts.Logger = NewSlog(io.Discard, false, false)
+	u := NewUpgrader(ctx, opts)
+	ln, err := u.Listener("http", "tcp", "127.0.0.1:0")
+	RequireNil(t, err)
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	started := make(chan *exec.Cmd, 1)
+	u.command = func() *exec.Cmd {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestUpgraderChild$")
+		cmd.Env = append(os.Environ(),
+			"NSTD_TEST_UPGRADE_CHILD="+mode,
+			"NSTD_TEST_UPGRADE_ADDR="+ln.Addr().String(),
+		)
+		cmd.Stdout, cmd.Stderr = io.Discard, io.Discard
+		started <- cmd
+		return cmd
+	}
+
+	return ctx, u, started
+}
+
+// waitReaped waits until the process pid has been reaped, a zombie still accepts signals.
+func waitReaped(t *testing.T, pid int) {
+	t.Helper()
+	for range 500 {
+		if errors.Is(syscall.Kill(pid, 0), syscall.ESRCH) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("process %d not reaped", pid)
+}
+
+func TestUpgrader(t *testing.T) {
+	t.Run("upgrade on syscall.SIGUSR2", func(t *testing.T) {
+		var src FakeSignalSource
+		ctx, u, started := newTestUpgrader(t, "ready", UpgraderOptions{Source: &src})
+		go func() {
+			_ = u.Run(ctx)
+		}()
+
+		for src.Send(syscall.SIGUSR2) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		<-ctx.Done()
+		RequireErrIs(t, context.Cause(ctx), ErrUpgraded)
+		RequireEqual(t, ExitCode(context.Cause(ctx)), 0)
+		waitReaped(t, (<-started).Process.Pid)
+	})
+
+	t.Run("keep serving if the new process exits", func(t *testing.T) {
+		ctx, u, started := newTestUpgrader(t, "exit", UpgraderOptions{})
+
+		err := u.Upgrade()
+		RequireNotNil(t, err)
+		RequireTrue(t, strings.HasSuffix(err.Error(), "exited before being ready"))
+		RequireNil(t, ctx.Err())
+		RequireTrue(t, (<-started).ProcessState != nil)
+	})
+
+	t.Run("kill the new process if it is not ready in time", func(t *testing.T) {
+		ctx, u, started := newTestUpgrader(t, "hang", UpgraderOptions{ReadyTimeout: 500 * time.Millisecond})
+
+		err := u.Upgrade()
+		RequireNotNil(t, err)
+		RequireTrue(t, strings.HasSuffix(err.Error(), "not ready within 500ms"))
+		RequireNil(t, ctx.Err())
+		cmd := <-started
+		RequireTrue(t, cmd.ProcessState != nil)
+		RequireTrue(t, !cmd.ProcessState.Success())
+	})
+
+	t.Run("add listeners while waiting for the new process", func(t *testing.T) {
+		_, u, started := newTestUpgrader(t, "hang", UpgraderOptions{ReadyTimeout: 500 * time.Millisecond})
+
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- u.Upgrade()
+		}()
+		<-started
+
+		ln, err := u.Listener("admin", "tcp", "127.0.0.1:0")
+		RequireNil(t, err)
+		defer ln.Close()
+		RequireEqual(t, u.Upgrade().Error(), "upgrade: already in progress")
+		RequireEqual(t, len(errChan), 0)
+
+		RequireNotNil(t, <-errChan)
+	})
+
+	t.Run("ready without upgrade", func(t *testing.T) {
+		_, u, _ := newTestUpgrader(t, "ready", UpgraderOptions{})
+		RequireNil(t, u.Ready())
+	})
+}
+
+func TestUpgradeEnviron(t *testing.T) {
+	env := upgradeEnviron([]string{"PATH=/bin", "LISTEN_FDS=2", "NSTD_UPGRADE_READY_FD=5", "HOME=/root"})
+	RequireEqual(t, strings.Join(env, ","), "PATH=/bin,HOME=/root")
+}